@@ -1,6 +1,23 @@
 package designpattern
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+const (
+	FactoryNameHuawei = "huawei"
+	FactoryNameApple  = "apple"
+)
+
+var (
+	// ErrUnknownFactory 表示注册表中没有该名称的工厂
+	ErrUnknownFactory = errors.New("unknown factory")
+	// ErrFactoryExists 表示该名称已经注册过工厂
+	ErrFactoryExists = errors.New("factory already registered")
+)
 
 type Factory interface {
 	Produce(product string) string
@@ -18,11 +35,97 @@ func (*Apple) Produce(product string) string {
 	return fmt.Sprintf("hi %s", product)
 }
 
-func NewFactory(factoryType int) Factory {
-	if factoryType == 1 {
-		return &Huawei{}
-	} else if factoryType == 2 {
-		return &Apple{}
+// FactoryConstructor 创建一个工厂实例
+type FactoryConstructor func() Factory
+
+// FactoryRegistry 按名称保存工厂构造函数，可并发使用
+type FactoryRegistry struct {
+	mu           sync.RWMutex
+	constructors map[string]FactoryConstructor
+}
+
+func NewFactoryRegistry() *FactoryRegistry {
+	return &FactoryRegistry{
+		constructors: make(map[string]FactoryConstructor),
+	}
+}
+
+// Register 注册工厂构造函数，名称重复时返回 ErrFactoryExists
+func (r *FactoryRegistry) Register(name string, constructor FactoryConstructor) error {
+	if name == "" {
+		return errors.New("factory name is empty")
 	}
+	if constructor == nil {
+		return fmt.Errorf("factory %q: constructor is nil", name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.constructors[name]; ok {
+		return fmt.Errorf("%w: %q", ErrFactoryExists, name)
+	}
+	r.constructors[name] = constructor
 	return nil
 }
+
+// Unregister 删除工厂，返回该名称之前是否已注册
+func (r *FactoryRegistry) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.constructors[name]; !ok {
+		return false
+	}
+	delete(r.constructors, name)
+	return true
+}
+
+// Names 返回已注册的工厂名称，按字母排序
+func (r *FactoryRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.constructors))
+	for name := range r.constructors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New 根据名称创建工厂，未注册时返回 ErrUnknownFactory
+func (r *FactoryRegistry) New(name string) (Factory, error) {
+	r.mu.RLock()
+	constructor, ok := r.constructors[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFactory, name)
+	}
+	return constructor(), nil
+}
+
+// 默认注册表，供包级函数使用
+var factoryRegistry = NewFactoryRegistry()
+
+func init() {
+	RegisterFactory(FactoryNameHuawei, func() Factory { return &Huawei{} })
+	RegisterFactory(FactoryNameApple, func() Factory { return &Apple{} })
+}
+
+// RegisterFactory 向默认注册表注册工厂
+func RegisterFactory(name string, constructor FactoryConstructor) error {
+	return factoryRegistry.Register(name, constructor)
+}
+
+// UnregisterFactory 从默认注册表删除工厂
+func UnregisterFactory(name string) bool {
+	return factoryRegistry.Unregister(name)
+}
+
+// Factories 返回默认注册表中的工厂名称
+func Factories() []string {
+	return factoryRegistry.Names()
+}
+
+// NewFactory 从默认注册表创建工厂
+func NewFactory(name string) (Factory, error) {
+	return factoryRegistry.New(name)
+}
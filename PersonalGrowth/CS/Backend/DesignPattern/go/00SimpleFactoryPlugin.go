@@ -0,0 +1,330 @@
+package designpattern
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/rpc"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// 进程外的工厂插件
+// 插件是一个独立的可执行文件，通过 stdin/stdout 上的 net/rpc 与宿主通信，
+// 宿主把它包装成普通的 Factory 使用，新增厂商不需要重新编译本包。
+
+const (
+	// FactoryPluginProtocolVersion 宿主与插件之间的协议版本，握手时必须一致
+	FactoryPluginProtocolVersion = 1
+
+	// 宿主通过环境变量告诉子进程它是被当作插件启动的
+	factoryPluginCookieKey   = "DESIGNPATTERN_FACTORY_PLUGIN"
+	factoryPluginCookieValue = "d3c0f6a2-factory-plugin"
+	factoryPluginService     = "FactoryPlugin"
+)
+
+var (
+	ErrNotPluginProcess       = errors.New("process was not started as a factory plugin")
+	ErrPluginVersion          = errors.New("factory plugin protocol version mismatch")
+	ErrPluginCrashed          = errors.New("factory plugin crashed")
+	ErrPluginRestartLimit     = errors.New("factory plugin restart limit reached")
+	ErrPluginClosed           = errors.New("factory plugin closed")
+	ErrPluginHandshakeTimeout = errors.New("factory plugin handshake timed out")
+)
+
+type PluginHandshakeArgs struct {
+	Version int
+}
+
+type PluginHandshakeReply struct {
+	Version int
+	Name    string
+}
+
+// FactoryPluginRPC 是插件进程内注册到 net/rpc 的服务
+type FactoryPluginRPC struct {
+	name    string
+	version int
+	factory Factory
+}
+
+func (s *FactoryPluginRPC) Handshake(args PluginHandshakeArgs, reply *PluginHandshakeReply) error {
+	reply.Version = s.version
+	reply.Name = s.name
+	return nil
+}
+
+func (s *FactoryPluginRPC) Produce(product string, reply *string) error {
+	*reply = s.factory.Produce(product)
+	return nil
+}
+
+// ServeFactoryPlugin 在插件进程的 main 中调用，阻塞直到宿主关闭连接。
+// 标准输出被用作 RPC 通道，因此调用期间 os.Stdout 会被重定向到 os.Stderr。
+func ServeFactoryPlugin(name string, factory Factory) error {
+	return serveFactoryPlugin(name, factory, FactoryPluginProtocolVersion)
+}
+
+func serveFactoryPlugin(name string, factory Factory, version int) error {
+	if os.Getenv(factoryPluginCookieKey) != factoryPluginCookieValue {
+		return ErrNotPluginProcess
+	}
+
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	defer func() { os.Stdout = stdout }()
+
+	server := rpc.NewServer()
+	err := server.RegisterName(factoryPluginService, &FactoryPluginRPC{
+		name:    name,
+		version: version,
+		factory: factory,
+	})
+	if err != nil {
+		return err
+	}
+	server.ServeConn(&pipeConn{Reader: os.Stdin, Writer: stdout, closers: []io.Closer{os.Stdin, stdout}})
+	return nil
+}
+
+// FactoryPluginConfig 描述如何启动插件进程
+type FactoryPluginConfig struct {
+	Path string
+	Args []string
+	Env  []string
+	// Stderr 接收插件的标准错误输出，为空时丢弃
+	Stderr io.Writer
+	// MaxRestarts 插件崩溃后允许自动重启的次数
+	MaxRestarts int
+	// HandshakeTimeout 默认 5 秒
+	HandshakeTimeout time.Duration
+	// ShutdownTimeout 关闭时等待插件退出的时间，超时后强制结束，默认 5 秒
+	ShutdownTimeout time.Duration
+}
+
+// FactoryPlugin 把插件进程包装成 Factory，可并发使用
+type FactoryPlugin struct {
+	config FactoryPluginConfig
+
+	mu       sync.Mutex
+	name     string
+	proc     *pluginProcess
+	restarts int
+	closed   bool
+}
+
+type pluginProcess struct {
+	cmd     *exec.Cmd
+	client  *rpc.Client
+	exited  chan struct{}
+	waitErr error
+}
+
+func (p *pluginProcess) alive() bool {
+	select {
+	case <-p.exited:
+		return false
+	default:
+		return true
+	}
+}
+
+func (p *pluginProcess) kill() {
+	p.client.Close()
+	p.cmd.Process.Kill()
+	<-p.exited
+}
+
+// NewFactoryPlugin 启动插件并完成握手
+func NewFactoryPlugin(config FactoryPluginConfig) (*FactoryPlugin, error) {
+	if config.HandshakeTimeout <= 0 {
+		config.HandshakeTimeout = 5 * time.Second
+	}
+	if config.ShutdownTimeout <= 0 {
+		config.ShutdownTimeout = 5 * time.Second
+	}
+
+	plugin := &FactoryPlugin{config: config}
+	proc, name, err := plugin.start()
+	if err != nil {
+		return nil, err
+	}
+	plugin.proc = proc
+	plugin.name = name
+	return plugin, nil
+}
+
+func (f *FactoryPlugin) start() (*pluginProcess, string, error) {
+	childStdin, hostWriter, err := os.Pipe()
+	if err != nil {
+		return nil, "", err
+	}
+	hostReader, childStdout, err := os.Pipe()
+	if err != nil {
+		childStdin.Close()
+		hostWriter.Close()
+		return nil, "", err
+	}
+
+	cmd := exec.Command(f.config.Path, f.config.Args...)
+	cmd.Env = append(os.Environ(), factoryPluginCookieKey+"="+factoryPluginCookieValue)
+	cmd.Env = append(cmd.Env, f.config.Env...)
+	cmd.Stdin = childStdin
+	cmd.Stdout = childStdout
+	cmd.Stderr = f.config.Stderr
+	err = cmd.Start()
+	childStdin.Close()
+	childStdout.Close()
+	if err != nil {
+		hostWriter.Close()
+		hostReader.Close()
+		return nil, "", fmt.Errorf("start factory plugin %s: %w", f.config.Path, err)
+	}
+
+	proc := &pluginProcess{
+		cmd:    cmd,
+		client: rpc.NewClient(&pipeConn{Reader: hostReader, Writer: hostWriter, closers: []io.Closer{hostWriter, hostReader}}),
+		exited: make(chan struct{}),
+	}
+	go func() {
+		proc.waitErr = cmd.Wait()
+		close(proc.exited)
+	}()
+
+	var reply PluginHandshakeReply
+	call := proc.client.Go(factoryPluginService+".Handshake", PluginHandshakeArgs{Version: FactoryPluginProtocolVersion}, &reply, nil)
+	select {
+	case <-call.Done:
+		err = call.Error
+	case <-time.After(f.config.HandshakeTimeout):
+		err = ErrPluginHandshakeTimeout
+	}
+	if err == nil && reply.Version != FactoryPluginProtocolVersion {
+		err = fmt.Errorf("%w: host %d, plugin %d", ErrPluginVersion, FactoryPluginProtocolVersion, reply.Version)
+	}
+	if err != nil {
+		proc.kill()
+		return nil, "", fmt.Errorf("handshake with factory plugin %s: %w", f.config.Path, err)
+	}
+	return proc, reply.Name, nil
+}
+
+// Name 返回插件在握手时报告的名称
+func (f *FactoryPlugin) Name() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.name
+}
+
+// Restarts 返回插件被重启的次数
+func (f *FactoryPlugin) Restarts() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.restarts
+}
+
+// Produce 实现 Factory 接口，远程调用失败时返回空字符串，需要错误信息时使用 TryProduce
+func (f *FactoryPlugin) Produce(product string) string {
+	result, _ := f.TryProduce(product)
+	return result
+}
+
+// TryProduce 把调用转发给插件进程。插件在调用过程中崩溃时返回 ErrPluginCrashed，
+// 调用不会被重试，下一次调用会先重启插件。
+func (f *FactoryPlugin) TryProduce(product string) (string, error) {
+	proc, err := f.process()
+	if err != nil {
+		return "", err
+	}
+
+	var result string
+	err = proc.client.Call(factoryPluginService+".Produce", product, &result)
+	if err == nil {
+		return result, nil
+	}
+	if _, ok := err.(rpc.ServerError); ok {
+		return "", err
+	}
+
+	// 连接断开说明插件已经不可用，结束进程以便下次调用时重启
+	proc.kill()
+	f.mu.Lock()
+	closed := f.closed
+	f.mu.Unlock()
+	if closed {
+		return "", ErrPluginClosed
+	}
+	return "", fmt.Errorf("%w: %v", ErrPluginCrashed, err)
+}
+
+// process 返回可用的插件进程，必要时重启
+func (f *FactoryPlugin) process() (*pluginProcess, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return nil, ErrPluginClosed
+	}
+	if f.proc.alive() {
+		return f.proc, nil
+	}
+
+	f.proc.client.Close()
+	if f.restarts >= f.config.MaxRestarts {
+		return nil, fmt.Errorf("%w: %d restarts, last exit: %v", ErrPluginRestartLimit, f.restarts, f.proc.waitErr)
+	}
+	f.restarts++
+	proc, name, err := f.start()
+	if err != nil {
+		return nil, err
+	}
+	f.proc = proc
+	f.name = name
+	return proc, nil
+}
+
+// Close 关闭与插件的连接并等待插件退出，超时后强制结束
+func (f *FactoryPlugin) Close() error {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return nil
+	}
+	f.closed = true
+	proc := f.proc
+	f.mu.Unlock()
+	if !proc.alive() {
+		// 插件已经退出，退出状态在之前的调用中已经报告过
+		proc.client.Close()
+		return nil
+	}
+
+	// 插件读到 EOF 后 ServeConn 返回，进程正常退出
+	proc.client.Close()
+	select {
+	case <-proc.exited:
+		return proc.waitErr
+	case <-time.After(f.config.ShutdownTimeout):
+		proc.cmd.Process.Kill()
+		<-proc.exited
+		return fmt.Errorf("factory plugin %s did not exit within %s", f.config.Path, f.config.ShutdownTimeout)
+	}
+}
+
+// pipeConn 把一对管道组合成 net/rpc 需要的 io.ReadWriteCloser
+type pipeConn struct {
+	io.Reader
+	io.Writer
+	closers []io.Closer
+}
+
+func (c *pipeConn) Close() error {
+	var errs []error
+	for _, closer := range c.closers {
+		if err := closer.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,132 @@
+package designpattern
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// 测试用的插件：重新执行当前测试二进制，只运行 TestFactoryPluginProcess
+type pluginVendor struct{}
+
+func (*pluginVendor) Produce(product string) string {
+	if product == "crash" {
+		os.Exit(3)
+	}
+	return fmt.Sprintf("plugin %s", product)
+}
+
+func TestFactoryPluginProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_FACTORY_PLUGIN") != "1" {
+		t.Skip("helper process for factory plugin tests")
+	}
+	version := FactoryPluginProtocolVersion
+	if v := os.Getenv("GO_FACTORY_PLUGIN_VERSION"); v != "" {
+		version, _ = strconv.Atoi(v)
+	}
+	if err := serveFactoryPlugin("xiaomi", &pluginVendor{}, version); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func newTestFactoryPlugin(t *testing.T, maxRestarts int, env ...string) (*FactoryPlugin, error) {
+	t.Helper()
+	return NewFactoryPlugin(FactoryPluginConfig{
+		Path:        os.Args[0],
+		Args:        []string{"-test.run=^TestFactoryPluginProcess$"},
+		Env:         append([]string{"GO_WANT_FACTORY_PLUGIN=1"}, env...),
+		Stderr:      os.Stderr,
+		MaxRestarts: maxRestarts,
+	})
+}
+
+func TestFactoryPlugin(t *testing.T) {
+	plugin, err := newTestFactoryPlugin(t, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plugin.Close()
+
+	if got := plugin.Name(); got != "xiaomi" {
+		t.Fatalf("Name() = %q, want xiaomi", got)
+	}
+
+	registry := NewFactoryRegistry()
+	if err := registry.Register(plugin.Name(), func() Factory { return plugin }); err != nil {
+		t.Fatal(err)
+	}
+	factory, err := registry.New("xiaomi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := factory.Produce("phone"); got != "plugin phone" {
+		t.Fatalf("Produce(phone) = %q, want %q", got, "plugin phone")
+	}
+
+	if err := plugin.Close(); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+	if _, err := plugin.TryProduce("phone"); !errors.Is(err, ErrPluginClosed) {
+		t.Fatalf("TryProduce after Close err = %v, want ErrPluginClosed", err)
+	}
+}
+
+func TestFactoryPluginRestart(t *testing.T) {
+	plugin, err := newTestFactoryPlugin(t, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plugin.Close()
+
+	if _, err := plugin.TryProduce("crash"); !errors.Is(err, ErrPluginCrashed) {
+		t.Fatalf("TryProduce(crash) err = %v, want ErrPluginCrashed", err)
+	}
+	if got, err := plugin.TryProduce("pad"); err != nil || got != "plugin pad" {
+		t.Fatalf("TryProduce(pad) = %q, %v", got, err)
+	}
+
+	// 空闲时进程被杀死也能在下一次调用时发现并重启
+	plugin.mu.Lock()
+	plugin.proc.cmd.Process.Kill()
+	exited := plugin.proc.exited
+	plugin.mu.Unlock()
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("plugin did not exit after kill")
+	}
+	if got, err := plugin.TryProduce("watch"); err != nil || got != "plugin watch" {
+		t.Fatalf("TryProduce(watch) = %q, %v", got, err)
+	}
+	if got := plugin.Restarts(); got != 2 {
+		t.Fatalf("Restarts() = %d, want 2", got)
+	}
+
+	if _, err := plugin.TryProduce("crash"); !errors.Is(err, ErrPluginCrashed) {
+		t.Fatalf("TryProduce(crash) err = %v, want ErrPluginCrashed", err)
+	}
+	if _, err := plugin.TryProduce("phone"); !errors.Is(err, ErrPluginRestartLimit) {
+		t.Fatalf("TryProduce after limit err = %v, want ErrPluginRestartLimit", err)
+	}
+}
+
+func TestFactoryPluginVersionMismatch(t *testing.T) {
+	_, err := newTestFactoryPlugin(t, 0, "GO_FACTORY_PLUGIN_VERSION=99")
+	if !errors.Is(err, ErrPluginVersion) {
+		t.Fatalf("err = %v, want ErrPluginVersion", err)
+	}
+}
+
+func TestServeFactoryPluginWithoutHost(t *testing.T) {
+	if os.Getenv(factoryPluginCookieKey) != "" {
+		t.Skip("running inside a plugin process")
+	}
+	if err := ServeFactoryPlugin("xiaomi", &pluginVendor{}); !errors.Is(err, ErrNotPluginProcess) {
+		t.Fatalf("err = %v, want ErrNotPluginProcess", err)
+	}
+}
@@ -1,12 +1,89 @@
 package designpattern
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
+	"sync"
 	"testing"
 )
 
 func TestSimpleFactory(t *testing.T) {
-	factory := NewFactory(1)
-	fmt.Println(factory.Produce("phone"))
+	factory, err := NewFactory(FactoryNameHuawei)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := factory.Produce("phone"); got != "hi phone" {
+		t.Fatalf("Produce(phone) = %q, want %q", got, "hi phone")
+	}
 }
 
+func TestSimpleFactoryUnknown(t *testing.T) {
+	factory, err := NewFactory("nokia")
+	if !errors.Is(err, ErrUnknownFactory) {
+		t.Fatalf("err = %v, want ErrUnknownFactory", err)
+	}
+	if factory != nil {
+		t.Fatalf("factory = %v, want nil", factory)
+	}
+}
+
+func TestFactoryRegistry(t *testing.T) {
+	registry := NewFactoryRegistry()
+	if err := registry.Register("apple", func() Factory { return &Apple{} }); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Register("huawei", func() Factory { return &Huawei{} }); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Register("apple", func() Factory { return &Apple{} }); !errors.Is(err, ErrFactoryExists) {
+		t.Fatalf("duplicate register err = %v, want ErrFactoryExists", err)
+	}
+
+	if got, want := registry.Names(), []string{"apple", "huawei"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Names() = %v, want %v", got, want)
+	}
+
+	factory, err := registry.New("apple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := factory.(*Apple); !ok {
+		t.Fatalf("New(apple) = %T, want *Apple", factory)
+	}
+
+	if !registry.Unregister("apple") {
+		t.Fatal("Unregister(apple) = false")
+	}
+	if registry.Unregister("apple") {
+		t.Fatal("second Unregister(apple) = true")
+	}
+	if _, err := registry.New("apple"); !errors.Is(err, ErrUnknownFactory) {
+		t.Fatalf("New after unregister err = %v, want ErrUnknownFactory", err)
+	}
+}
+
+func TestFactoryRegistryConcurrent(t *testing.T) {
+	registry := NewFactoryRegistry()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("vendor-%d", i)
+			if err := registry.Register(name, func() Factory { return &Huawei{} }); err != nil {
+				t.Error(err)
+				return
+			}
+			if _, err := registry.New(name); err != nil {
+				t.Error(err)
+			}
+			registry.Names()
+		}(i)
+	}
+	wg.Wait()
+
+	if got := len(registry.Names()); got != 50 {
+		t.Fatalf("len(Names()) = %d, want 50", got)
+	}
+}
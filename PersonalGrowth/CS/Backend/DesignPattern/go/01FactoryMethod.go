@@ -1,17 +1,33 @@
 package designpattern
 
+import (
+	"errors"
+	"fmt"
+)
+
 type OperatorType string
 
 const (
-	OperatorPlus  OperatorType = "plus"
-	OperatorMinus OperatorType = "minus"
+	OperatorPlus     OperatorType = "plus"
+	OperatorMinus    OperatorType = "minus"
+	OperatorMultiply OperatorType = "multiply"
+	OperatorDivide   OperatorType = "divide"
+	OperatorModulo   OperatorType = "modulo"
+	OperatorPower    OperatorType = "power"
+)
+
+var (
+	ErrUnknownOperator  = errors.New("unknown operator")
+	ErrOverflow         = errors.New("integer overflow")
+	ErrDivisionByZero   = errors.New("division by zero")
+	ErrNegativeExponent = errors.New("negative exponent")
 )
 
 // 方法
 type Operator interface {
 	SetA(int)
 	SetB(int)
-	Result() int
+	Result() (int, error)
 }
 
 // 方法基类
@@ -32,8 +48,8 @@ type PlusOperator struct {
 	*OperatorBase
 }
 
-func (o *PlusOperator) Result() int {
-	return o.a + o.b
+func (o *PlusOperator) Result() (int, error) {
+	return integerAdd(o.a, o.b)
 }
 
 // 减法
@@ -41,8 +57,44 @@ type MinusOperator struct {
 	*OperatorBase
 }
 
-func (o *MinusOperator) Result() int {
-	return o.a - o.b
+func (o *MinusOperator) Result() (int, error) {
+	return integerSub(o.a, o.b)
+}
+
+// 乘法
+type MultiplyOperator struct {
+	*OperatorBase
+}
+
+func (o *MultiplyOperator) Result() (int, error) {
+	return integerMul(o.a, o.b)
+}
+
+// 除法，结果向零取整
+type DivideOperator struct {
+	*OperatorBase
+}
+
+func (o *DivideOperator) Result() (int, error) {
+	return integerDiv(o.a, o.b)
+}
+
+// 取模，结果符号与被除数相同
+type ModuloOperator struct {
+	*OperatorBase
+}
+
+func (o *ModuloOperator) Result() (int, error) {
+	return integerMod(o.a, o.b)
+}
+
+// 乘方，a 的 b 次方
+type PowerOperator struct {
+	*OperatorBase
+}
+
+func (o *PowerOperator) Result() (int, error) {
+	return integerPow(o.a, o.b)
 }
 
 // 工厂方法
@@ -68,15 +120,60 @@ func (f *MinusOperatorFactory) Create() Operator {
 	}
 }
 
-// 创建方法工厂类
-func CreateFactory(operator OperatorType) Operator {
+// 乘法工厂类
+type MultiplyOperatorFactory struct{ OperatorFactory }
+
+func (f *MultiplyOperatorFactory) Create() Operator {
+	return &MultiplyOperator{
+		OperatorBase: &OperatorBase{},
+	}
+}
+
+// 除法工厂类
+type DivideOperatorFactory struct{ OperatorFactory }
+
+func (f *DivideOperatorFactory) Create() Operator {
+	return &DivideOperator{
+		OperatorBase: &OperatorBase{},
+	}
+}
+
+// 取模工厂类
+type ModuloOperatorFactory struct{ OperatorFactory }
+
+func (f *ModuloOperatorFactory) Create() Operator {
+	return &ModuloOperator{
+		OperatorBase: &OperatorBase{},
+	}
+}
+
+// 乘方工厂类
+type PowerOperatorFactory struct{ OperatorFactory }
+
+func (f *PowerOperatorFactory) Create() Operator {
+	return &PowerOperator{
+		OperatorBase: &OperatorBase{},
+	}
+}
+
+// 创建方法工厂类，未知的运算类型返回 ErrUnknownOperator
+func CreateFactory(operator OperatorType) (Operator, error) {
+	var factory OperatorFactory
 	switch operator {
 	case OperatorPlus:
-		factory := &PlusOperatorFactory{}
-		return factory.Create()
+		factory = &PlusOperatorFactory{}
 	case OperatorMinus:
-		factory := &MinusOperatorFactory{}
-		return factory.Create()
+		factory = &MinusOperatorFactory{}
+	case OperatorMultiply:
+		factory = &MultiplyOperatorFactory{}
+	case OperatorDivide:
+		factory = &DivideOperatorFactory{}
+	case OperatorModulo:
+		factory = &ModuloOperatorFactory{}
+	case OperatorPower:
+		factory = &PowerOperatorFactory{}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownOperator, operator)
 	}
-	return nil
+	return factory.Create(), nil
 }
@@ -0,0 +1,184 @@
+package designpattern
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// 批量计算
+// 对大量数据逐个调用 SetA/SetB/Result 需要经过接口调用和堆上的 Operator，
+// 批量接口直接对切片循环，并可以分段并行。
+
+var ErrLengthMismatch = errors.New("input slices have different lengths")
+
+// OperandPair 一组操作数，用于按列存储的数据
+type OperandPair struct {
+	A, B int
+}
+
+// BatchError 记录批量计算中第一个出错的位置
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch index %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// BatchOptions 控制并行计算
+type BatchOptions struct {
+	// Workers 并行的 goroutine 数量，小于等于 0 时使用 runtime.GOMAXPROCS(0)
+	Workers int
+	// MinChunk 每个 goroutine 至少处理的元素数量，数据太少时不值得并行，默认 4096
+	MinChunk int
+}
+
+func batchFunc(operator OperatorType) (binaryFunc[int], error) {
+	apply, ok := integerOps[int]()[operator]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownOperator, operator)
+	}
+	return apply, nil
+}
+
+// ApplyBatch 对 a、b 中对应位置的元素做运算，结果写入 out 并返回。
+// out 长度不足时会重新分配。出错时返回 *BatchError，out 中出错位置之后的结果无意义。
+func ApplyBatch(operator OperatorType, a, b, out []int) ([]int, error) {
+	apply, err := batchFunc(operator)
+	if err != nil {
+		return nil, err
+	}
+	if len(a) != len(b) {
+		return nil, fmt.Errorf("%w: %d != %d", ErrLengthMismatch, len(a), len(b))
+	}
+	out = batchOutput(out, len(a))
+	if err := applyRange(operator, apply, a, b, out, 0); err != nil {
+		return out, err
+	}
+	return out, nil
+}
+
+// ApplyPairs 与 ApplyBatch 相同，输入为按列存储的操作数
+func ApplyPairs(operator OperatorType, pairs []OperandPair, out []int) ([]int, error) {
+	apply, err := batchFunc(operator)
+	if err != nil {
+		return nil, err
+	}
+	out = batchOutput(out, len(pairs))
+	for i, pair := range pairs {
+		result, err := apply(pair.A, pair.B)
+		if err != nil {
+			return out, &BatchError{Index: i, Err: err}
+		}
+		out[i] = result
+	}
+	return out, nil
+}
+
+// ApplyBatchParallel 把数据分段交给多个 goroutine 计算，
+// 出错时返回下标最小的 *BatchError
+func ApplyBatchParallel(operator OperatorType, a, b, out []int, opts BatchOptions) ([]int, error) {
+	apply, err := batchFunc(operator)
+	if err != nil {
+		return nil, err
+	}
+	if len(a) != len(b) {
+		return nil, fmt.Errorf("%w: %d != %d", ErrLengthMismatch, len(a), len(b))
+	}
+	out = batchOutput(out, len(a))
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	minChunk := opts.MinChunk
+	if minChunk <= 0 {
+		minChunk = 4096
+	}
+	if limit := (len(a) + minChunk - 1) / minChunk; workers > limit {
+		workers = limit
+	}
+	if workers <= 1 {
+		if err := applyRange(operator, apply, a, b, out, 0); err != nil {
+			return out, err
+		}
+		return out, nil
+	}
+
+	chunk := (len(a) + workers - 1) / workers
+	errs := make([]*BatchError, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		start := w * chunk
+		end := start + chunk
+		if end > len(a) {
+			end = len(a)
+		}
+		if start >= end {
+			break
+		}
+		wg.Add(1)
+		go func(w, start, end int) {
+			defer wg.Done()
+			if err := applyRange(operator, apply, a[start:end], b[start:end], out[start:end], start); err != nil {
+				errs[w] = err
+			}
+		}(w, start, end)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+// applyRange 计算一段数据，加法和减法直接内联在循环里
+func applyRange(operator OperatorType, apply binaryFunc[int], a, b, out []int, offset int) *BatchError {
+	b = b[:len(a)]
+	out = out[:len(a)]
+	switch operator {
+	case OperatorPlus:
+		for i := range a {
+			c := a[i] + b[i]
+			if (b[i] > 0 && c < a[i]) || (b[i] < 0 && c > a[i]) {
+				return &BatchError{Index: offset + i, Err: fmt.Errorf("%w: %d + %d", ErrOverflow, a[i], b[i])}
+			}
+			out[i] = c
+		}
+		return nil
+	case OperatorMinus:
+		for i := range a {
+			c := a[i] - b[i]
+			if (b[i] > 0 && c > a[i]) || (b[i] < 0 && c < a[i]) {
+				return &BatchError{Index: offset + i, Err: fmt.Errorf("%w: %d - %d", ErrOverflow, a[i], b[i])}
+			}
+			out[i] = c
+		}
+		return nil
+	}
+	for i := range a {
+		result, err := apply(a[i], b[i])
+		if err != nil {
+			return &BatchError{Index: offset + i, Err: err}
+		}
+		out[i] = result
+	}
+	return nil
+}
+
+func batchOutput(out []int, n int) []int {
+	if cap(out) < n {
+		return make([]int, n)
+	}
+	return out[:n]
+}
@@ -0,0 +1,146 @@
+package designpattern
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestApplyBatch(t *testing.T) {
+	a := []int{1, 2, 3, 4}
+	b := []int{10, 20, 30, 40}
+
+	out, err := ApplyBatch(OperatorPlus, a, b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{11, 22, 33, 44}; !reflect.DeepEqual(out, want) {
+		t.Fatalf("plus = %v, want %v", out, want)
+	}
+
+	out, err = ApplyBatch(OperatorMultiply, a, b, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{10, 40, 90, 160}; !reflect.DeepEqual(out, want) {
+		t.Fatalf("multiply = %v, want %v", out, want)
+	}
+
+	pairs := []OperandPair{{7, 2}, {9, 3}}
+	out, err = ApplyPairs(OperatorDivide, pairs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{3, 3}; !reflect.DeepEqual(out, want) {
+		t.Fatalf("divide pairs = %v, want %v", out, want)
+	}
+}
+
+func TestApplyBatchErrors(t *testing.T) {
+	if _, err := ApplyBatch(OperatorPlus, []int{1}, []int{1, 2}, nil); !errors.Is(err, ErrLengthMismatch) {
+		t.Fatalf("err = %v, want ErrLengthMismatch", err)
+	}
+	if _, err := ApplyBatch("sqrt", nil, nil, nil); !errors.Is(err, ErrUnknownOperator) {
+		t.Fatalf("err = %v, want ErrUnknownOperator", err)
+	}
+
+	_, err := ApplyBatch(OperatorMinus, []int{1, math.MinInt, 3}, []int{1, 1, 1}, nil)
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || batchErr.Index != 1 || !errors.Is(err, ErrOverflow) {
+		t.Fatalf("err = %v, want overflow at index 1", err)
+	}
+
+	_, err = ApplyPairs(OperatorModulo, []OperandPair{{1, 1}, {1, 0}}, nil)
+	if !errors.As(err, &batchErr) || batchErr.Index != 1 || !errors.Is(err, ErrDivisionByZero) {
+		t.Fatalf("err = %v, want division by zero at index 1", err)
+	}
+}
+
+func TestApplyBatchParallel(t *testing.T) {
+	const n = 100003
+	a, b := batchInputs(n)
+	want, err := ApplyBatch(OperatorMinus, a, b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ApplyBatchParallel(OperatorMinus, a, b, nil, BatchOptions{Workers: 7, MinChunk: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatal("parallel result differs from sequential result")
+	}
+
+	// 多个位置出错时返回下标最小的那个
+	b[n-10] = 0
+	b[500] = 0
+	_, err = ApplyBatchParallel(OperatorDivide, a, b, nil, BatchOptions{Workers: 4, MinChunk: 1000})
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || batchErr.Index != 500 {
+		t.Fatalf("err = %v, want error at index 500", err)
+	}
+}
+
+func batchInputs(n int) ([]int, []int) {
+	a := make([]int, n)
+	b := make([]int, n)
+	for i := range a {
+		a[i] = i * 3
+		b[i] = i%97 + 1
+	}
+	return a, b
+}
+
+const benchmarkBatchSize = 1 << 20
+
+func BenchmarkOperatorPerCall(b *testing.B) {
+	x, y := batchInputs(benchmarkBatchSize)
+	out := make([]int, benchmarkBatchSize)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for i := range x {
+			operator, _ := CreateFactory(OperatorPlus)
+			operator.SetA(x[i])
+			operator.SetB(y[i])
+			out[i], _ = operator.Result()
+		}
+	}
+}
+
+func BenchmarkOperatorPerCallReused(b *testing.B) {
+	x, y := batchInputs(benchmarkBatchSize)
+	out := make([]int, benchmarkBatchSize)
+	operator, _ := CreateFactory(OperatorPlus)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for i := range x {
+			operator.SetA(x[i])
+			operator.SetB(y[i])
+			out[i], _ = operator.Result()
+		}
+	}
+}
+
+func BenchmarkApplyBatch(b *testing.B) {
+	x, y := batchInputs(benchmarkBatchSize)
+	out := make([]int, benchmarkBatchSize)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if _, err := ApplyBatch(OperatorPlus, x, y, out); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkApplyBatchParallel(b *testing.B) {
+	x, y := batchInputs(benchmarkBatchSize)
+	out := make([]int, benchmarkBatchSize)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if _, err := ApplyBatchParallel(OperatorPlus, x, y, out, BatchOptions{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
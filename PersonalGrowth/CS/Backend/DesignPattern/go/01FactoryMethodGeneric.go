@@ -0,0 +1,345 @@
+package designpattern
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// 泛型版本的工厂方法，支持任意整数、浮点数以及 math/big 的高精度运算
+
+var ErrNonIntegerExponent = errors.New("non-integer exponent")
+
+type Signed interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64
+}
+
+type Unsigned interface {
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+type Integer interface {
+	Signed | Unsigned
+}
+
+type Float interface {
+	~float32 | ~float64
+}
+
+// 泛型方法
+type NumericOperator[T any] interface {
+	SetA(T)
+	SetB(T)
+	Result() (T, error)
+}
+
+// 泛型方法基类
+type NumericOperatorBase[T any] struct {
+	a, b T
+}
+
+func (o *NumericOperatorBase[T]) SetA(a T) {
+	o.a = a
+}
+
+func (o *NumericOperatorBase[T]) SetB(b T) {
+	o.b = b
+}
+
+// 二元运算
+type binaryFunc[T any] func(a, b T) (T, error)
+
+type numericOperator[T any] struct {
+	*NumericOperatorBase[T]
+	apply binaryFunc[T]
+}
+
+func (o *numericOperator[T]) Result() (T, error) {
+	return o.apply(o.a, o.b)
+}
+
+// 泛型工厂方法
+type NumericOperatorFactory[T any] interface {
+	Create() NumericOperator[T]
+}
+
+// 泛型工厂类，每次 Create 返回一个新的运算
+type numericOperatorFactory[T any] struct {
+	apply binaryFunc[T]
+}
+
+func (f *numericOperatorFactory[T]) Create() NumericOperator[T] {
+	return &numericOperator[T]{
+		NumericOperatorBase: &NumericOperatorBase[T]{},
+		apply:               f.apply,
+	}
+}
+
+func newNumericOperatorFactory[T any](operator OperatorType, ops map[OperatorType]binaryFunc[T]) (NumericOperatorFactory[T], error) {
+	apply, ok := ops[operator]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownOperator, operator)
+	}
+	return &numericOperatorFactory[T]{apply: apply}, nil
+}
+
+// 整数运算，溢出时返回 ErrOverflow
+func integerOps[T Integer]() map[OperatorType]binaryFunc[T] {
+	return map[OperatorType]binaryFunc[T]{
+		OperatorPlus:     integerAdd[T],
+		OperatorMinus:    integerSub[T],
+		OperatorMultiply: integerMul[T],
+		OperatorDivide:   integerDiv[T],
+		OperatorModulo:   integerMod[T],
+		OperatorPower:    integerPow[T],
+	}
+}
+
+func isSigned[T Integer]() bool {
+	var zero T
+	return zero-1 < zero
+}
+
+// isMinSigned 判断 a 是否为有符号类型的最小值，只有最小值的相反数等于它本身
+func isMinSigned[T Integer](a T) bool {
+	return isSigned[T]() && a != 0 && a == -a
+}
+
+func integerAdd[T Integer](a, b T) (T, error) {
+	c := a + b
+	if (isSigned[T]() && ((b > 0 && c < a) || (b < 0 && c > a))) || (!isSigned[T]() && c < a) {
+		return 0, fmt.Errorf("%w: %v + %v", ErrOverflow, a, b)
+	}
+	return c, nil
+}
+
+func integerSub[T Integer](a, b T) (T, error) {
+	c := a - b
+	if (isSigned[T]() && ((b > 0 && c > a) || (b < 0 && c < a))) || (!isSigned[T]() && b > a) {
+		return 0, fmt.Errorf("%w: %v - %v", ErrOverflow, a, b)
+	}
+	return c, nil
+}
+
+func integerMul[T Integer](a, b T) (T, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	c := a * b
+	if c/b != a || (isMinSigned(a) && b == ^T(0)) || (isMinSigned(b) && a == ^T(0)) {
+		return 0, fmt.Errorf("%w: %v * %v", ErrOverflow, a, b)
+	}
+	return c, nil
+}
+
+func integerDiv[T Integer](a, b T) (T, error) {
+	if b == 0 {
+		return 0, fmt.Errorf("%w: %v / %v", ErrDivisionByZero, a, b)
+	}
+	if isMinSigned(a) && b == ^T(0) {
+		return 0, fmt.Errorf("%w: %v / %v", ErrOverflow, a, b)
+	}
+	return a / b, nil
+}
+
+func integerMod[T Integer](a, b T) (T, error) {
+	if b == 0 {
+		return 0, fmt.Errorf("%w: %v %% %v", ErrDivisionByZero, a, b)
+	}
+	return a % b, nil
+}
+
+func integerPow[T Integer](a, b T) (T, error) {
+	if b < 0 {
+		return 0, fmt.Errorf("%w: %v ^ %v", ErrNegativeExponent, a, b)
+	}
+	result, base, exp := T(1), a, b
+	for exp > 0 {
+		var err error
+		if exp&1 == 1 {
+			if result, err = integerMul(result, base); err != nil {
+				return 0, fmt.Errorf("%w: %v ^ %v", ErrOverflow, a, b)
+			}
+		}
+		exp >>= 1
+		if exp > 0 {
+			if base, err = integerMul(base, base); err != nil {
+				return 0, fmt.Errorf("%w: %v ^ %v", ErrOverflow, a, b)
+			}
+		}
+	}
+	return result, nil
+}
+
+// 浮点运算，有限的输入得到无穷大时返回 ErrOverflow
+func floatOps[T Float]() map[OperatorType]binaryFunc[T] {
+	return map[OperatorType]binaryFunc[T]{
+		OperatorPlus: func(a, b T) (T, error) {
+			return checkFloat(a+b, "%v + %v", a, b)
+		},
+		OperatorMinus: func(a, b T) (T, error) {
+			return checkFloat(a-b, "%v - %v", a, b)
+		},
+		OperatorMultiply: func(a, b T) (T, error) {
+			return checkFloat(a*b, "%v * %v", a, b)
+		},
+		OperatorDivide: func(a, b T) (T, error) {
+			if b == 0 {
+				return 0, fmt.Errorf("%w: %v / %v", ErrDivisionByZero, a, b)
+			}
+			return checkFloat(a/b, "%v / %v", a, b)
+		},
+		OperatorModulo: func(a, b T) (T, error) {
+			if b == 0 {
+				return 0, fmt.Errorf("%w: %v %% %v", ErrDivisionByZero, a, b)
+			}
+			return T(math.Mod(float64(a), float64(b))), nil
+		},
+		OperatorPower: func(a, b T) (T, error) {
+			return checkFloat(T(math.Pow(float64(a), float64(b))), "%v ^ %v", a, b)
+		},
+	}
+}
+
+func checkFloat[T Float](c T, format string, a, b T) (T, error) {
+	if math.IsInf(float64(c), 0) && !math.IsInf(float64(a), 0) && !math.IsInf(float64(b), 0) {
+		return 0, fmt.Errorf("%w: "+format, ErrOverflow, a, b)
+	}
+	return c, nil
+}
+
+// big.Int 运算，不会溢出；除法和取模与 int 一样向零取整
+func bigIntOps() map[OperatorType]binaryFunc[*big.Int] {
+	return map[OperatorType]binaryFunc[*big.Int]{
+		OperatorPlus: func(a, b *big.Int) (*big.Int, error) {
+			return new(big.Int).Add(bigIntOrZero(a), bigIntOrZero(b)), nil
+		},
+		OperatorMinus: func(a, b *big.Int) (*big.Int, error) {
+			return new(big.Int).Sub(bigIntOrZero(a), bigIntOrZero(b)), nil
+		},
+		OperatorMultiply: func(a, b *big.Int) (*big.Int, error) {
+			return new(big.Int).Mul(bigIntOrZero(a), bigIntOrZero(b)), nil
+		},
+		OperatorDivide: func(a, b *big.Int) (*big.Int, error) {
+			a, b = bigIntOrZero(a), bigIntOrZero(b)
+			if b.Sign() == 0 {
+				return nil, fmt.Errorf("%w: %v / %v", ErrDivisionByZero, a, b)
+			}
+			return new(big.Int).Quo(a, b), nil
+		},
+		OperatorModulo: func(a, b *big.Int) (*big.Int, error) {
+			a, b = bigIntOrZero(a), bigIntOrZero(b)
+			if b.Sign() == 0 {
+				return nil, fmt.Errorf("%w: %v %% %v", ErrDivisionByZero, a, b)
+			}
+			return new(big.Int).Rem(a, b), nil
+		},
+		OperatorPower: func(a, b *big.Int) (*big.Int, error) {
+			a, b = bigIntOrZero(a), bigIntOrZero(b)
+			if b.Sign() < 0 {
+				return nil, fmt.Errorf("%w: %v ^ %v", ErrNegativeExponent, a, b)
+			}
+			return new(big.Int).Exp(a, b, nil), nil
+		},
+	}
+}
+
+func bigIntOrZero(x *big.Int) *big.Int {
+	if x == nil {
+		return new(big.Int)
+	}
+	return x
+}
+
+// big.Rat 运算，结果是精确的分数；乘方的指数必须是整数
+func bigRatOps() map[OperatorType]binaryFunc[*big.Rat] {
+	return map[OperatorType]binaryFunc[*big.Rat]{
+		OperatorPlus: func(a, b *big.Rat) (*big.Rat, error) {
+			return new(big.Rat).Add(bigRatOrZero(a), bigRatOrZero(b)), nil
+		},
+		OperatorMinus: func(a, b *big.Rat) (*big.Rat, error) {
+			return new(big.Rat).Sub(bigRatOrZero(a), bigRatOrZero(b)), nil
+		},
+		OperatorMultiply: func(a, b *big.Rat) (*big.Rat, error) {
+			return new(big.Rat).Mul(bigRatOrZero(a), bigRatOrZero(b)), nil
+		},
+		OperatorDivide: func(a, b *big.Rat) (*big.Rat, error) {
+			a, b = bigRatOrZero(a), bigRatOrZero(b)
+			if b.Sign() == 0 {
+				return nil, fmt.Errorf("%w: %v / %v", ErrDivisionByZero, a.RatString(), b.RatString())
+			}
+			return new(big.Rat).Quo(a, b), nil
+		},
+		OperatorModulo: func(a, b *big.Rat) (*big.Rat, error) {
+			a, b = bigRatOrZero(a), bigRatOrZero(b)
+			if b.Sign() == 0 {
+				return nil, fmt.Errorf("%w: %v %% %v", ErrDivisionByZero, a.RatString(), b.RatString())
+			}
+			// a - b*trunc(a/b)，与整数取模一样结果符号与被除数相同
+			q := new(big.Rat).Quo(a, b)
+			trunc := new(big.Int).Quo(q.Num(), q.Denom())
+			r := new(big.Rat).Mul(b, new(big.Rat).SetInt(trunc))
+			return r.Sub(a, r), nil
+		},
+		OperatorPower: func(a, b *big.Rat) (*big.Rat, error) {
+			a, b = bigRatOrZero(a), bigRatOrZero(b)
+			if !b.IsInt() {
+				return nil, fmt.Errorf("%w: %v ^ %v", ErrNonIntegerExponent, a.RatString(), b.RatString())
+			}
+			exp := new(big.Int).Abs(b.Num())
+			num := new(big.Int).Exp(a.Num(), exp, nil)
+			denom := new(big.Int).Exp(a.Denom(), exp, nil)
+			if b.Sign() < 0 {
+				if a.Sign() == 0 {
+					return nil, fmt.Errorf("%w: %v ^ %v", ErrDivisionByZero, a.RatString(), b.RatString())
+				}
+				num, denom = denom, num
+			}
+			return new(big.Rat).SetFrac(num, denom), nil
+		},
+	}
+}
+
+func bigRatOrZero(x *big.Rat) *big.Rat {
+	if x == nil {
+		return new(big.Rat)
+	}
+	return x
+}
+
+// CreateIntegerFactory 创建整数运算
+func CreateIntegerFactory[T Integer](operator OperatorType) (NumericOperator[T], error) {
+	factory, err := newNumericOperatorFactory(operator, integerOps[T]())
+	if err != nil {
+		return nil, err
+	}
+	return factory.Create(), nil
+}
+
+// CreateFloatFactory 创建浮点运算
+func CreateFloatFactory[T Float](operator OperatorType) (NumericOperator[T], error) {
+	factory, err := newNumericOperatorFactory(operator, floatOps[T]())
+	if err != nil {
+		return nil, err
+	}
+	return factory.Create(), nil
+}
+
+// CreateBigIntFactory 创建任意精度整数运算
+func CreateBigIntFactory(operator OperatorType) (NumericOperator[*big.Int], error) {
+	factory, err := newNumericOperatorFactory(operator, bigIntOps())
+	if err != nil {
+		return nil, err
+	}
+	return factory.Create(), nil
+}
+
+// CreateBigRatFactory 创建精确分数运算，适合金额计算
+func CreateBigRatFactory(operator OperatorType) (NumericOperator[*big.Rat], error) {
+	factory, err := newNumericOperatorFactory(operator, bigRatOps())
+	if err != nil {
+		return nil, err
+	}
+	return factory.Create(), nil
+}
@@ -0,0 +1,172 @@
+package designpattern
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestIntegerOperatorFactory(t *testing.T) {
+	plus, err := CreateIntegerFactory[int64](OperatorPlus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plus.SetA(math.MaxInt64 - 1)
+	plus.SetB(1)
+	if got, err := plus.Result(); err != nil || got != math.MaxInt64 {
+		t.Fatalf("Result() = %d, %v", got, err)
+	}
+	plus.SetB(2)
+	if _, err := plus.Result(); !errors.Is(err, ErrOverflow) {
+		t.Fatalf("err = %v, want ErrOverflow", err)
+	}
+
+	tests := []struct {
+		operator OperatorType
+		a, b     int8
+		want     int8
+		err      error
+	}{
+		{OperatorMinus, -128, 1, 0, ErrOverflow},
+		{OperatorMultiply, -128, -1, 0, ErrOverflow},
+		{OperatorMultiply, -1, -128, 0, ErrOverflow},
+		{OperatorMultiply, 16, 8, 0, ErrOverflow},
+		{OperatorMultiply, -16, 8, -128, nil},
+		{OperatorDivide, -128, -1, 0, ErrOverflow},
+		{OperatorModulo, -128, -1, 0, nil},
+		{OperatorPower, 2, 6, 64, nil},
+		{OperatorPower, 2, 7, 0, ErrOverflow},
+		{OperatorPower, -2, 7, -128, nil},
+	}
+	for _, tt := range tests {
+		operator, err := CreateIntegerFactory[int8](tt.operator)
+		if err != nil {
+			t.Fatal(err)
+		}
+		operator.SetA(tt.a)
+		operator.SetB(tt.b)
+		got, err := operator.Result()
+		if !errors.Is(err, tt.err) || (err == nil && got != tt.want) {
+			t.Errorf("%s(%d, %d) = %d, %v; want %d, %v", tt.operator, tt.a, tt.b, got, err, tt.want, tt.err)
+		}
+	}
+
+	minus, err := CreateIntegerFactory[uint8](OperatorMinus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	minus.SetA(1)
+	minus.SetB(2)
+	if _, err := minus.Result(); !errors.Is(err, ErrOverflow) {
+		t.Fatalf("uint8 1 - 2 err = %v, want ErrOverflow", err)
+	}
+}
+
+func TestFloatOperatorFactory(t *testing.T) {
+	multiply, err := CreateFloatFactory[float64](OperatorMultiply)
+	if err != nil {
+		t.Fatal(err)
+	}
+	multiply.SetA(1.5)
+	multiply.SetB(-4)
+	if got, err := multiply.Result(); err != nil || got != -6 {
+		t.Fatalf("Result() = %v, %v", got, err)
+	}
+	multiply.SetA(math.MaxFloat64)
+	multiply.SetB(2)
+	if _, err := multiply.Result(); !errors.Is(err, ErrOverflow) {
+		t.Fatalf("err = %v, want ErrOverflow", err)
+	}
+
+	divide, err := CreateFloatFactory[float32](OperatorDivide)
+	if err != nil {
+		t.Fatal(err)
+	}
+	divide.SetA(1)
+	if _, err := divide.Result(); !errors.Is(err, ErrDivisionByZero) {
+		t.Fatalf("err = %v, want ErrDivisionByZero", err)
+	}
+}
+
+func TestBigIntOperatorFactory(t *testing.T) {
+	power, err := CreateBigIntFactory(OperatorPower)
+	if err != nil {
+		t.Fatal(err)
+	}
+	power.SetA(big.NewInt(2))
+	power.SetB(big.NewInt(100))
+	got, err := power.Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "1267650600228229401496703205376"; got.String() != want {
+		t.Fatalf("2^100 = %s, want %s", got, want)
+	}
+
+	modulo, err := CreateBigIntFactory(OperatorModulo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modulo.SetA(big.NewInt(-7))
+	modulo.SetB(big.NewInt(3))
+	if got, err := modulo.Result(); err != nil || got.Int64() != -1 {
+		t.Fatalf("-7 %% 3 = %v, %v", got, err)
+	}
+	modulo.SetB(nil)
+	if _, err := modulo.Result(); !errors.Is(err, ErrDivisionByZero) {
+		t.Fatalf("err = %v, want ErrDivisionByZero", err)
+	}
+}
+
+func TestBigRatOperatorFactory(t *testing.T) {
+	price, _ := new(big.Rat).SetString("19.99")
+	plus, err := CreateBigRatFactory(OperatorPlus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plus.SetA(price)
+	plus.SetB(big.NewRat(1, 100))
+	if got, err := plus.Result(); err != nil || got.FloatString(2) != "20.00" {
+		t.Fatalf("19.99 + 0.01 = %v, %v", got, err)
+	}
+	if price.FloatString(2) != "19.99" {
+		t.Fatalf("operand was modified: %s", price.FloatString(2))
+	}
+
+	tests := []struct {
+		operator OperatorType
+		a, b     *big.Rat
+		want     string
+		err      error
+	}{
+		{OperatorDivide, big.NewRat(1, 3), big.NewRat(2, 1), "1/6", nil},
+		{OperatorDivide, big.NewRat(1, 3), new(big.Rat), "", ErrDivisionByZero},
+		{OperatorModulo, big.NewRat(7, 2), big.NewRat(1, 1), "1/2", nil},
+		{OperatorModulo, big.NewRat(-7, 2), big.NewRat(1, 1), "-1/2", nil},
+		{OperatorPower, big.NewRat(2, 3), big.NewRat(-2, 1), "9/4", nil},
+		{OperatorPower, big.NewRat(2, 3), big.NewRat(1, 2), "", ErrNonIntegerExponent},
+		{OperatorPower, new(big.Rat), big.NewRat(-1, 1), "", ErrDivisionByZero},
+	}
+	for _, tt := range tests {
+		operator, err := CreateBigRatFactory(tt.operator)
+		if err != nil {
+			t.Fatal(err)
+		}
+		operator.SetA(tt.a)
+		operator.SetB(tt.b)
+		got, err := operator.Result()
+		if !errors.Is(err, tt.err) || (err == nil && got.RatString() != tt.want) {
+			t.Errorf("%s(%s, %s) = %v, %v; want %s, %v", tt.operator, tt.a.RatString(), tt.b.RatString(), got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestNumericOperatorFactoryUnknown(t *testing.T) {
+	if _, err := CreateIntegerFactory[int](OperatorType("sqrt")); !errors.Is(err, ErrUnknownOperator) {
+		t.Fatalf("err = %v, want ErrUnknownOperator", err)
+	}
+	if _, err := CreateBigRatFactory(OperatorType("sqrt")); !errors.Is(err, ErrUnknownOperator) {
+		t.Fatalf("err = %v, want ErrUnknownOperator", err)
+	}
+}
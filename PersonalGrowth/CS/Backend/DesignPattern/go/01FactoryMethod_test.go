@@ -1,20 +1,99 @@
 package designpattern
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"testing"
 )
 
 func TestPlusOperatorFactory(t *testing.T) {
-	operator := CreateFactory(OperatorPlus)
+	operator, err := CreateFactory(OperatorPlus)
+	if err != nil {
+		t.Fatal(err)
+	}
 	operator.SetA(1)
 	operator.SetB(3)
-	fmt.Print(operator.Result())
+	if got, err := operator.Result(); err != nil || got != 4 {
+		t.Fatalf("Result() = %d, %v; want 4", got, err)
+	}
 }
 
 func TestMinusOperatorFactory(t *testing.T) {
-	operator := CreateFactory(OperatorMinus)
+	operator, err := CreateFactory(OperatorMinus)
+	if err != nil {
+		t.Fatal(err)
+	}
 	operator.SetA(1)
 	operator.SetB(3)
-	fmt.Print(operator.Result())
+	if got, err := operator.Result(); err != nil || got != -2 {
+		t.Fatalf("Result() = %d, %v; want -2", got, err)
+	}
+}
+
+func TestOperatorResults(t *testing.T) {
+	tests := []struct {
+		operator OperatorType
+		a, b     int
+		want     int
+		err      error
+	}{
+		{OperatorPlus, 1, 3, 4, nil},
+		{OperatorPlus, math.MaxInt, 1, 0, ErrOverflow},
+		{OperatorPlus, math.MinInt, -1, 0, ErrOverflow},
+		{OperatorMinus, 1, 3, -2, nil},
+		{OperatorMinus, math.MinInt, 1, 0, ErrOverflow},
+		{OperatorMinus, math.MaxInt, -1, 0, ErrOverflow},
+		{OperatorMultiply, -4, 6, -24, nil},
+		{OperatorMultiply, math.MaxInt, 2, 0, ErrOverflow},
+		{OperatorMultiply, math.MinInt, -1, 0, ErrOverflow},
+		{OperatorMultiply, -1, math.MinInt, 0, ErrOverflow},
+		{OperatorDivide, 7, 2, 3, nil},
+		{OperatorDivide, -7, 2, -3, nil},
+		{OperatorDivide, 7, 0, 0, ErrDivisionByZero},
+		{OperatorDivide, math.MinInt, -1, 0, ErrOverflow},
+		{OperatorModulo, 7, 3, 1, nil},
+		{OperatorModulo, -7, 3, -1, nil},
+		{OperatorModulo, 7, 0, 0, ErrDivisionByZero},
+		{OperatorPower, 2, 10, 1024, nil},
+		{OperatorPower, -3, 3, -27, nil},
+		{OperatorPower, 5, 0, 1, nil},
+		{OperatorPower, 2, 30, 1 << 30, nil},
+		{OperatorPower, 2, 64, 0, ErrOverflow},
+		{OperatorPower, math.MaxInt, 2, 0, ErrOverflow},
+		{OperatorPower, 2, -1, 0, ErrNegativeExponent},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s(%d,%d)", tt.operator, tt.a, tt.b), func(t *testing.T) {
+			operator, err := CreateFactory(tt.operator)
+			if err != nil {
+				t.Fatal(err)
+			}
+			operator.SetA(tt.a)
+			operator.SetB(tt.b)
+			got, err := operator.Result()
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("err = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("Result() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateFactoryUnknown(t *testing.T) {
+	operator, err := CreateFactory("sqrt")
+	if !errors.Is(err, ErrUnknownOperator) {
+		t.Fatalf("err = %v, want ErrUnknownOperator", err)
+	}
+	if operator != nil {
+		t.Fatalf("operator = %v, want nil", operator)
+	}
 }
@@ -1,6 +1,9 @@
 package designpattern
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 const (
 	FactoryHuawei = iota
@@ -8,31 +11,101 @@ const (
 	FactoryUnsupported
 )
 
-// 抽象工厂接口,需要能够生产手机和Ipad
+// ProductKind 产品种类
+type ProductKind string
+
+const (
+	ProductCellphone     ProductKind = "cellphone"
+	ProductIpad          ProductKind = "ipad"
+	ProductSmartSoundBox ProductKind = "smart_sound_box"
+)
+
+// AllProductKinds 所有产品种类，按固定顺序排列
+var AllProductKinds = []ProductKind{ProductCellphone, ProductIpad, ProductSmartSoundBox}
+
+var (
+	// ErrProductNotSupported 表示工厂不生产该种类的产品
+	ErrProductNotSupported = errors.New("product not supported")
+	// ErrFactoryNotSupported 表示超级工厂无法创建该类型的工厂
+	ErrFactoryNotSupported = errors.New("factory not supported")
+)
+
+// 抽象工厂接口,需要能够生产手机、Ipad和智能音箱，并声明自己支持哪些产品
 type AbstractFactory interface {
-	CreateCellphone() Cellphone
-	CreateIpad() Ipad
-	CreateSmartSoundBox() SmartSoundBox
+	Name() string
+	Products() []ProductKind
+	Supports(kind ProductKind) bool
+	CreateCellphone() (Cellphone, error)
+	CreateIpad() (Ipad, error)
+	CreateSmartSoundBox() (SmartSoundBox, error)
 }
 
 // 超级工厂接口，创建一个工厂
 type HyperFactory interface {
-	CreateFactory(typ int) AbstractFactory
+	CreateFactory(typ int) (AbstractFactory, error)
 }
 
 // 超级工厂实例
 type HypeFactoryImpl struct{}
 
+// 所有已知的工厂类型
+var factoryTypes = []int{FactoryHuawei, FactoryXiaomi}
+
 // 根据给定参数创建工厂
-func (*HypeFactoryImpl) CreateFactory(typ int) AbstractFactory {
+func (*HypeFactoryImpl) CreateFactory(typ int) (AbstractFactory, error) {
 	switch typ {
 	case FactoryHuawei:
-		return &HuaweiFactory{}
+		return &HuaweiFactory{}, nil
 	case FactoryXiaomi:
-		return &XiaomiFactory{}
+		return &XiaomiFactory{}, nil
 	default:
-		return nil
+		return nil, fmt.Errorf("%w: %d", ErrFactoryNotSupported, typ)
+	}
+}
+
+// Families 返回所有产品族的工厂
+func (h *HypeFactoryImpl) Families() []AbstractFactory {
+	families := make([]AbstractFactory, 0, len(factoryTypes))
+	for _, typ := range factoryTypes {
+		factory, _ := h.CreateFactory(typ)
+		families = append(families, factory)
+	}
+	return families
+}
+
+// CatalogEntry 产品族与产品种类的组合，Supported 表示该产品族是否生产这种产品
+type CatalogEntry struct {
+	Family    string
+	Product   ProductKind
+	Supported bool
+}
+
+// Catalog 列出所有产品族和所有产品种类的组合，不支持的组合也会列出
+func (h *HypeFactoryImpl) Catalog() []CatalogEntry {
+	var entries []CatalogEntry
+	for _, factory := range h.Families() {
+		for _, kind := range AllProductKinds {
+			entries = append(entries, CatalogEntry{
+				Family:    factory.Name(),
+				Product:   kind,
+				Supported: factory.Supports(kind),
+			})
+		}
+	}
+	return entries
+}
+
+func supportsProduct(products []ProductKind, kind ProductKind) bool {
+	for _, p := range products {
+		if p == kind {
+			return true
+		}
 	}
+	return false
+}
+
+func productNotSupported(family string, kind ProductKind) error {
+	return fmt.Errorf("%w: %s does not produce %s", ErrProductNotSupported, family, kind)
 }
 
 // 手机接口
@@ -50,69 +123,92 @@ type SmartSoundBox interface {
 	Listen()
 }
 
-// 华为工厂,实现了抽象工厂的两个接口
+// 华为工厂,生产手机和Ipad
 type HuaweiFactory struct{}
 
-func (*HuaweiFactory) CreateCellphone() Cellphone {
-	return &HuaweiCellphone{}
+func (*HuaweiFactory) Name() string {
+	return "Huawei"
+}
+
+func (*HuaweiFactory) Products() []ProductKind {
+	return []ProductKind{ProductCellphone, ProductIpad}
+}
+
+func (f *HuaweiFactory) Supports(kind ProductKind) bool {
+	return supportsProduct(f.Products(), kind)
 }
 
-func (*HuaweiFactory) CreateIpad() Ipad {
-	return &HuaweiIpad{}
+func (*HuaweiFactory) CreateCellphone() (Cellphone, error) {
+	return &HuaweiCellphone{}, nil
+}
+
+func (*HuaweiFactory) CreateIpad() (Ipad, error) {
+	return &HuaweiIpad{}, nil
 }
 
 // 华为工厂不生产智能音箱
-func (*HuaweiFactory) CreateSmartSoundBox() SmartSoundBox {
-	fmt.Println("Huawei not produce SmartSoundBox")
-	return nil
+func (f *HuaweiFactory) CreateSmartSoundBox() (SmartSoundBox, error) {
+	return nil, productNotSupported(f.Name(), ProductSmartSoundBox)
 }
 
 // 华为手机，实现了手机接口
 type HuaweiCellphone struct{}
 
 func (*HuaweiCellphone) Call() {
-	fmt.Println("I made a call on my HuaweiCellphone")
+	emit("HuaweiCellphone", "I made a call on my HuaweiCellphone")
 }
 
 // 华为Ipad
 type HuaweiIpad struct{}
 
 func (*HuaweiIpad) Play() {
-	fmt.Println("I am playing with HuaweiIpad")
+	emit("HuaweiIpad", "I am playing with HuaweiIpad")
 }
 
-// 小米工厂,实现了抽象工厂的两个接口
+// 小米工厂,生产手机、Ipad和智能音箱
 type XiaomiFactory struct{}
 
-func (*XiaomiFactory) CreateCellphone() Cellphone {
-	return &XiaomiCellphone{}
+func (*XiaomiFactory) Name() string {
+	return "Xiaomi"
+}
+
+func (*XiaomiFactory) Products() []ProductKind {
+	return []ProductKind{ProductCellphone, ProductIpad, ProductSmartSoundBox}
+}
+
+func (f *XiaomiFactory) Supports(kind ProductKind) bool {
+	return supportsProduct(f.Products(), kind)
+}
+
+func (*XiaomiFactory) CreateCellphone() (Cellphone, error) {
+	return &XiaomiCellphone{}, nil
 }
 
-func (*XiaomiFactory) CreateIpad() Ipad {
-	return &XiaomiIpad{}
+func (*XiaomiFactory) CreateIpad() (Ipad, error) {
+	return &XiaomiIpad{}, nil
 }
 
-func (*XiaomiFactory) CreateSmartSoundBox() SmartSoundBox {
-	return &XiaomiSmartSoundBox{}
+func (*XiaomiFactory) CreateSmartSoundBox() (SmartSoundBox, error) {
+	return &XiaomiSmartSoundBox{}, nil
 }
 
 // 小米手机，实现了手机接口
 type XiaomiCellphone struct{}
 
 func (*XiaomiCellphone) Call() {
-	fmt.Println("I made a call on my XiaomiCellphone")
+	emit("XiaomiCellphone", "I made a call on my XiaomiCellphone")
 }
 
 // 小米Ipad
 type XiaomiIpad struct{}
 
 func (*XiaomiIpad) Play() {
-	fmt.Println("I am playing with XiaomiIpad")
+	emit("XiaomiIpad", "I am playing with XiaomiIpad")
 }
 
 // 小米智能音箱
 type XiaomiSmartSoundBox struct{}
 
 func (*XiaomiSmartSoundBox) Listen() {
-	fmt.Println("I am listening with XiaomiSmartSoundBox")
+	emit("XiaomiSmartSoundBox", "I am listening with XiaomiSmartSoundBox")
 }
@@ -0,0 +1,263 @@
+package designpattern
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// 设备生态
+// 同一产品族的设备可以直接配对，不同产品族之间只能通过注册的适配器配对。
+
+// Capability 配对后源设备对目标设备的能力
+type Capability string
+
+const (
+	// CapabilityCast 投屏，例如手机投屏到平板
+	CapabilityCast Capability = "cast"
+	// CapabilityControl 控制，例如手机控制音箱
+	CapabilityControl Capability = "control"
+)
+
+// PairingNative 表示同一产品族之间的原生配对
+const PairingNative = "native"
+
+var (
+	ErrDeviceNotFound      = errors.New("device not found")
+	ErrDeviceExists        = errors.New("device already exists")
+	ErrPairingNotSupported = errors.New("pairing not supported")
+	ErrAdapterRequired     = errors.New("cross-family pairing requires an adapter")
+)
+
+// Device 由各产品族的产品实现
+type Device interface {
+	Family() string
+	Kind() ProductKind
+}
+
+func (*HuaweiCellphone) Family() string        { return "Huawei" }
+func (*HuaweiCellphone) Kind() ProductKind     { return ProductCellphone }
+func (*HuaweiIpad) Family() string             { return "Huawei" }
+func (*HuaweiIpad) Kind() ProductKind          { return ProductIpad }
+func (*XiaomiCellphone) Family() string        { return "Xiaomi" }
+func (*XiaomiCellphone) Kind() ProductKind     { return ProductCellphone }
+func (*XiaomiIpad) Family() string             { return "Xiaomi" }
+func (*XiaomiIpad) Kind() ProductKind          { return ProductIpad }
+func (*XiaomiSmartSoundBox) Family() string    { return "Xiaomi" }
+func (*XiaomiSmartSoundBox) Kind() ProductKind { return ProductSmartSoundBox }
+
+type pairingRule struct {
+	source, target ProductKind
+}
+
+// 哪种产品可以对哪种产品使用哪种能力
+var pairingRules = map[pairingRule][]Capability{
+	{ProductCellphone, ProductIpad}:          {CapabilityCast},
+	{ProductCellphone, ProductSmartSoundBox}: {CapabilityControl},
+	{ProductIpad, ProductSmartSoundBox}:      {CapabilityControl},
+}
+
+func kindsSupport(source, target ProductKind, capability Capability) bool {
+	for _, c := range pairingRules[pairingRule{source, target}] {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// PairingAdapter 让 From 产品族的设备对 To 产品族的设备使用指定能力
+type PairingAdapter struct {
+	Name         string
+	From, To     string
+	Capabilities []Capability
+}
+
+func (a *PairingAdapter) supports(from, to string, capability Capability) bool {
+	if a.From != from || a.To != to {
+		return false
+	}
+	for _, c := range a.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// Pairing 配对图中的一条边，Via 为 PairingNative 或适配器名称
+type Pairing struct {
+	Source     string
+	Target     string
+	Capability Capability
+	Via        string
+}
+
+// Ecosystem 保存设备、适配器和配对关系，可并发使用
+type Ecosystem struct {
+	mu       sync.RWMutex
+	devices  map[string]Device
+	adapters []*PairingAdapter
+	pairings map[string][]Pairing // 按源设备索引
+}
+
+func NewEcosystem() *Ecosystem {
+	return &Ecosystem{
+		devices:  make(map[string]Device),
+		pairings: make(map[string][]Pairing),
+	}
+}
+
+// AddDevice 以 id 加入设备
+func (e *Ecosystem) AddDevice(id string, device Device) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.devices[id]; ok {
+		return fmt.Errorf("%w: %s", ErrDeviceExists, id)
+	}
+	e.devices[id] = device
+	return nil
+}
+
+// RemoveDevice 删除设备以及与它相关的所有配对
+func (e *Ecosystem) RemoveDevice(id string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.devices[id]; !ok {
+		return fmt.Errorf("%w: %s", ErrDeviceNotFound, id)
+	}
+	delete(e.devices, id)
+	delete(e.pairings, id)
+	for source, pairings := range e.pairings {
+		kept := pairings[:0]
+		for _, p := range pairings {
+			if p.Target != id {
+				kept = append(kept, p)
+			}
+		}
+		e.pairings[source] = kept
+	}
+	return nil
+}
+
+// RegisterAdapter 注册跨产品族的适配器
+func (e *Ecosystem) RegisterAdapter(adapter *PairingAdapter) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.adapters = append(e.adapters, adapter)
+}
+
+// CanPair 判断 source 能否对 target 使用 capability，返回配对方式
+func (e *Ecosystem) CanPair(source, target Device, capability Capability) (string, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.canPair(source, target, capability)
+}
+
+func (e *Ecosystem) canPair(source, target Device, capability Capability) (string, error) {
+	if !kindsSupport(source.Kind(), target.Kind(), capability) {
+		return "", fmt.Errorf("%w: %s cannot %s %s", ErrPairingNotSupported, source.Kind(), capability, target.Kind())
+	}
+	if source.Family() == target.Family() {
+		return PairingNative, nil
+	}
+	for _, adapter := range e.adapters {
+		if adapter.supports(source.Family(), target.Family(), capability) {
+			return adapter.Name, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s %s -> %s %s", ErrAdapterRequired, source.Family(), source.Kind(), target.Family(), target.Kind())
+}
+
+// Pair 建立配对，重复配对返回已有的配对
+func (e *Ecosystem) Pair(sourceID, targetID string, capability Capability) (Pairing, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	source, target, err := e.lookup(sourceID, targetID)
+	if err != nil {
+		return Pairing{}, err
+	}
+	for _, p := range e.pairings[sourceID] {
+		if p.Target == targetID && p.Capability == capability {
+			return p, nil
+		}
+	}
+	via, err := e.canPair(source, target, capability)
+	if err != nil {
+		return Pairing{}, err
+	}
+	pairing := Pairing{Source: sourceID, Target: targetID, Capability: capability, Via: via}
+	e.pairings[sourceID] = append(e.pairings[sourceID], pairing)
+	return pairing, nil
+}
+
+// Unpair 解除配对，返回之前是否存在该配对
+func (e *Ecosystem) Unpair(sourceID, targetID string, capability Capability) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	pairings := e.pairings[sourceID]
+	for i, p := range pairings {
+		if p.Target == targetID && p.Capability == capability {
+			e.pairings[sourceID] = append(pairings[:i], pairings[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Ecosystem) lookup(sourceID, targetID string) (Device, Device, error) {
+	source, ok := e.devices[sourceID]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s", ErrDeviceNotFound, sourceID)
+	}
+	target, ok := e.devices[targetID]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s", ErrDeviceNotFound, targetID)
+	}
+	return source, target, nil
+}
+
+// Pairings 返回以 id 为源设备的所有配对
+func (e *Ecosystem) Pairings(id string) []Pairing {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	pairings := make([]Pairing, len(e.pairings[id]))
+	copy(pairings, e.pairings[id])
+	return pairings
+}
+
+// Targets 返回 id 已配对且可以使用 capability 的目标设备，按 id 排序
+func (e *Ecosystem) Targets(id string, capability Capability) []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	var targets []string
+	for _, p := range e.pairings[id] {
+		if p.Capability == capability {
+			targets = append(targets, p.Target)
+		}
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// Candidates 返回生态中 id 可以配对并使用 capability 的设备，不论是否已经配对，按 id 排序
+func (e *Ecosystem) Candidates(id string, capability Capability) ([]string, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	source, ok := e.devices[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrDeviceNotFound, id)
+	}
+	var candidates []string
+	for targetID, target := range e.devices {
+		if targetID == id {
+			continue
+		}
+		if _, err := e.canPair(source, target, capability); err == nil {
+			candidates = append(candidates, targetID)
+		}
+	}
+	sort.Strings(candidates)
+	return candidates, nil
+}
@@ -0,0 +1,146 @@
+package designpattern
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func newTestEcosystem(t *testing.T) *Ecosystem {
+	t.Helper()
+	hyper := &HypeFactoryImpl{}
+	huawei, _ := hyper.CreateFactory(FactoryHuawei)
+	xiaomi, _ := hyper.CreateFactory(FactoryXiaomi)
+
+	huaweiPhone, _ := huawei.CreateCellphone()
+	huaweiPad, _ := huawei.CreateIpad()
+	xiaomiPhone, _ := xiaomi.CreateCellphone()
+	xiaomiPad, _ := xiaomi.CreateIpad()
+	xiaomiBox, _ := xiaomi.CreateSmartSoundBox()
+
+	ecosystem := NewEcosystem()
+	for id, device := range map[string]Device{
+		"huawei-phone": huaweiPhone.(Device),
+		"huawei-pad":   huaweiPad.(Device),
+		"xiaomi-phone": xiaomiPhone.(Device),
+		"xiaomi-pad":   xiaomiPad.(Device),
+		"xiaomi-box":   xiaomiBox.(Device),
+	} {
+		if err := ecosystem.AddDevice(id, device); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ecosystem
+}
+
+func TestEcosystemNativePairing(t *testing.T) {
+	ecosystem := newTestEcosystem(t)
+
+	pairing, err := ecosystem.Pair("huawei-phone", "huawei-pad", CapabilityCast)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pairing.Via != PairingNative {
+		t.Fatalf("Via = %q, want native", pairing.Via)
+	}
+
+	if _, err := ecosystem.Pair("xiaomi-phone", "xiaomi-box", CapabilityControl); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ecosystem.Pair("xiaomi-pad", "xiaomi-box", CapabilityControl); err != nil {
+		t.Fatal(err)
+	}
+	if got := ecosystem.Targets("xiaomi-phone", CapabilityControl); !reflect.DeepEqual(got, []string{"xiaomi-box"}) {
+		t.Fatalf("xiaomi-phone controls %v", got)
+	}
+
+	// 平板不能投屏到手机，音箱也不能控制手机
+	if _, err := ecosystem.Pair("huawei-pad", "huawei-phone", CapabilityCast); !errors.Is(err, ErrPairingNotSupported) {
+		t.Fatalf("err = %v, want ErrPairingNotSupported", err)
+	}
+	if _, err := ecosystem.Pair("xiaomi-box", "xiaomi-phone", CapabilityControl); !errors.Is(err, ErrPairingNotSupported) {
+		t.Fatalf("err = %v, want ErrPairingNotSupported", err)
+	}
+}
+
+func TestEcosystemCrossFamilyPairing(t *testing.T) {
+	ecosystem := newTestEcosystem(t)
+
+	if _, err := ecosystem.Pair("huawei-phone", "xiaomi-box", CapabilityControl); !errors.Is(err, ErrAdapterRequired) {
+		t.Fatalf("err = %v, want ErrAdapterRequired", err)
+	}
+	candidates, err := ecosystem.Candidates("huawei-phone", CapabilityControl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 0 {
+		t.Fatalf("candidates without adapter = %v", candidates)
+	}
+
+	ecosystem.RegisterAdapter(&PairingAdapter{
+		Name:         "hilink-mihome",
+		From:         "Huawei",
+		To:           "Xiaomi",
+		Capabilities: []Capability{CapabilityControl},
+	})
+
+	pairing, err := ecosystem.Pair("huawei-phone", "xiaomi-box", CapabilityControl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pairing.Via != "hilink-mihome" {
+		t.Fatalf("Via = %q, want hilink-mihome", pairing.Via)
+	}
+
+	// 适配器是单向的，并且只提供声明的能力
+	if _, err := ecosystem.Pair("huawei-phone", "xiaomi-pad", CapabilityCast); !errors.Is(err, ErrAdapterRequired) {
+		t.Fatalf("cast err = %v, want ErrAdapterRequired", err)
+	}
+	if _, err := ecosystem.Pair("xiaomi-phone", "huawei-pad", CapabilityCast); !errors.Is(err, ErrAdapterRequired) {
+		t.Fatalf("reverse err = %v, want ErrAdapterRequired", err)
+	}
+
+	candidates, err = ecosystem.Candidates("huawei-phone", CapabilityControl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"xiaomi-box"}; !reflect.DeepEqual(candidates, want) {
+		t.Fatalf("candidates = %v, want %v", candidates, want)
+	}
+	candidates, _ = ecosystem.Candidates("xiaomi-phone", CapabilityCast)
+	if want := []string{"xiaomi-pad"}; !reflect.DeepEqual(candidates, want) {
+		t.Fatalf("xiaomi-phone cast candidates = %v, want %v", candidates, want)
+	}
+}
+
+func TestEcosystemGraphUpdates(t *testing.T) {
+	ecosystem := newTestEcosystem(t)
+	if _, err := ecosystem.Pair("xiaomi-phone", "xiaomi-pad", CapabilityCast); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ecosystem.Pair("xiaomi-phone", "xiaomi-box", CapabilityControl); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ecosystem.Pair("xiaomi-phone", "xiaomi-box", CapabilityControl); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(ecosystem.Pairings("xiaomi-phone")); got != 2 {
+		t.Fatalf("len(Pairings) = %d, want 2", got)
+	}
+
+	if !ecosystem.Unpair("xiaomi-phone", "xiaomi-pad", CapabilityCast) {
+		t.Fatal("Unpair returned false")
+	}
+	if err := ecosystem.RemoveDevice("xiaomi-box"); err != nil {
+		t.Fatal(err)
+	}
+	if got := ecosystem.Pairings("xiaomi-phone"); len(got) != 0 {
+		t.Fatalf("Pairings after removal = %v", got)
+	}
+	if _, err := ecosystem.Pair("xiaomi-phone", "xiaomi-box", CapabilityControl); !errors.Is(err, ErrDeviceNotFound) {
+		t.Fatalf("err = %v, want ErrDeviceNotFound", err)
+	}
+	if err := ecosystem.AddDevice("xiaomi-phone", &XiaomiCellphone{}); !errors.Is(err, ErrDeviceExists) {
+		t.Fatalf("err = %v, want ErrDeviceExists", err)
+	}
+}
@@ -1,15 +1,84 @@
 package designpattern
 
-import "testing"
+import (
+	"errors"
+	"testing"
+)
 
 func TestAbstractFactory(t *testing.T) {
+	recorder := recordOutput(t)
 	factory := &HypeFactoryImpl{}
-	huaweiFactory := factory.CreateFactory(FactoryHuawei)
-	xiaomiFactory := factory.CreateFactory(FactoryXiaomi)
+	huaweiFactory, err := factory.CreateFactory(FactoryHuawei)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xiaomiFactory, err := factory.CreateFactory(FactoryXiaomi)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	huaweiCellphone := huaweiFactory.CreateCellphone()
-	xiaomiCellphone := xiaomiFactory.CreateCellphone()
+	huaweiCellphone, err := huaweiFactory.CreateCellphone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	xiaomiCellphone, err := xiaomiFactory.CreateCellphone()
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	huaweiCellphone.Call()
 	xiaomiCellphone.Call()
+	assertMessages(t, recorder,
+		"I made a call on my HuaweiCellphone",
+		"I made a call on my XiaomiCellphone",
+	)
+}
+
+func TestAbstractFactoryUnsupported(t *testing.T) {
+	factory := &HypeFactoryImpl{}
+	if _, err := factory.CreateFactory(FactoryUnsupported); !errors.Is(err, ErrFactoryNotSupported) {
+		t.Fatalf("err = %v, want ErrFactoryNotSupported", err)
+	}
+
+	huaweiFactory, _ := factory.CreateFactory(FactoryHuawei)
+	if huaweiFactory.Supports(ProductSmartSoundBox) {
+		t.Fatal("Huawei should not support SmartSoundBox")
+	}
+	soundBox, err := huaweiFactory.CreateSmartSoundBox()
+	if !errors.Is(err, ErrProductNotSupported) {
+		t.Fatalf("err = %v, want ErrProductNotSupported", err)
+	}
+	if soundBox != nil {
+		t.Fatalf("soundBox = %v, want nil", soundBox)
+	}
+}
+
+func TestAbstractFactoryCatalog(t *testing.T) {
+	factory := &HypeFactoryImpl{}
+	families := factory.Families()
+	if len(families) != 2 {
+		t.Fatalf("len(Families()) = %d, want 2", len(families))
+	}
+
+	supported := make(map[string]map[ProductKind]bool)
+	for _, entry := range factory.Catalog() {
+		if supported[entry.Family] == nil {
+			supported[entry.Family] = make(map[ProductKind]bool)
+		}
+		supported[entry.Family][entry.Product] = entry.Supported
+	}
+	for _, family := range families {
+		for _, kind := range AllProductKinds {
+			got, ok := supported[family.Name()][kind]
+			if !ok {
+				t.Fatalf("catalog missing %s/%s", family.Name(), kind)
+			}
+			if got != family.Supports(kind) {
+				t.Fatalf("catalog %s/%s = %v, want %v", family.Name(), kind, got, family.Supports(kind))
+			}
+		}
+	}
+	if supported["Huawei"][ProductSmartSoundBox] || !supported["Xiaomi"][ProductSmartSoundBox] {
+		t.Fatalf("unexpected sound box support: %v", supported)
+	}
 }
@@ -10,7 +10,9 @@ type Builder interface {
 }
 
 // Director 是指挥者
-type Director struct{}
+type Director struct {
+	recipes map[string]Recipe
+}
 
 // Build 使用生成器构建
 func (d *Director) Build(builder Builder) {
@@ -26,15 +28,15 @@ type CarBuilder struct {
 }
 
 func (b *CarBuilder) AddWheel() {
-	b.car.AddPart("Car Wheel")
+	b.car.AddPart(carWheel)
 }
 
 func (b *CarBuilder) AddEngine() {
-	b.car.AddPart("Car Engine")
+	b.car.AddPart(carEngine)
 }
 
 func (b *CarBuilder) AddDoors() {
-	b.car.AddPart("Car Doors")
+	b.car.AddPart(carDoors)
 }
 
 func (b *CarBuilder) GetVehicle() Vehicle {
@@ -51,15 +53,15 @@ type TruckBuilder struct {
 }
 
 func (b *TruckBuilder) AddWheel() {
-	b.truck.AddPart("Truck Engine")
+	b.truck.AddPart(truckWheel)
 }
 
 func (b *TruckBuilder) AddEngine() {
-	b.truck.AddPart("Truck Engine")
+	b.truck.AddPart(truckEngine)
 }
 
 func (b *TruckBuilder) AddDoors() {
-	b.truck.AddPart("Truck Doors")
+	b.truck.AddPart(truckDoors)
 }
 
 func (b *TruckBuilder) GetVehicle() Vehicle {
@@ -72,23 +74,34 @@ func (b *TruckBuilder) CreateVehicle() {
 
 // Vehicle 是车辆接口
 type Vehicle interface {
-	AddPart(part string)
+	AddPart(part Part)
+	Parts() []Part
 }
 
 // Car 是具体的汽车
 type Car struct {
-	parts []string
+	parts []Part
 }
 
-func (c *Car) AddPart(part string) {
+func (c *Car) AddPart(part Part) {
 	c.parts = append(c.parts, part)
 }
 
+// Parts 返回已安装的部件
+func (c *Car) Parts() []Part {
+	return append([]Part(nil), c.parts...)
+}
+
 // Truck 是具体的卡车
 type Truck struct {
-	parts []string
+	parts []Part
 }
 
-func (t *Truck) AddPart(part string) {
+func (t *Truck) AddPart(part Part) {
 	t.parts = append(t.parts, part)
 }
+
+// Parts 返回已安装的部件
+func (t *Truck) Parts() []Part {
+	return append([]Part(nil), t.parts...)
+}
@@ -0,0 +1,154 @@
+package designpattern
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// 物料清单
+// 车辆由带料号、数量、重量和单价的部件组成，BillOfMaterials 按料号汇总成本和重量，
+// 可以导出为 CSV 和 JSON。
+
+// Part 车辆部件，重量单位为克，单价单位为分
+type Part struct {
+	Number   string   `json:"number"`
+	Name     string   `json:"name"`
+	Kind     PartKind `json:"kind"`
+	Quantity int      `json:"quantity"`
+	Weight   int64    `json:"unit_weight_g"`
+	UnitCost int64    `json:"unit_cost_cents"`
+}
+
+// TotalWeight 返回该部件的总重量
+func (p Part) TotalWeight() int64 {
+	return p.Weight * int64(p.Quantity)
+}
+
+// TotalCost 返回该部件的总成本
+func (p Part) TotalCost() int64 {
+	return p.UnitCost * int64(p.Quantity)
+}
+
+func (p Part) validate() error {
+	switch {
+	case p.Number == "":
+		return fmt.Errorf("%w: %s has no part number", ErrInvalidPart, p.Name)
+	case p.Quantity < 1:
+		return fmt.Errorf("%w: %s quantity %d", ErrInvalidPart, p.Number, p.Quantity)
+	case p.Weight < 0 || p.UnitCost < 0:
+		return fmt.Errorf("%w: %s has negative weight or cost", ErrInvalidPart, p.Number)
+	}
+	return nil
+}
+
+// CarBuilder 和 TruckBuilder 使用的标准部件
+var (
+	carWheel    = Part{Number: "CW-100", Name: "Car Wheel", Kind: PartWheels, Quantity: 4, Weight: 9500, UnitCost: 8900}
+	carEngine   = Part{Number: "CE-200", Name: "Car Engine", Kind: PartEngine, Quantity: 1, Weight: 150000, UnitCost: 350000}
+	carDoors    = Part{Number: "CD-300", Name: "Car Doors", Kind: PartDoors, Quantity: 4, Weight: 25000, UnitCost: 42000}
+	truckWheel  = Part{Number: "TW-100", Name: "Truck Wheel", Kind: PartWheels, Quantity: 6, Weight: 45000, UnitCost: 26000}
+	truckEngine = Part{Number: "TE-200", Name: "Truck Engine", Kind: PartEngine, Quantity: 1, Weight: 900000, UnitCost: 1200000}
+	truckDoors  = Part{Number: "TD-300", Name: "Truck Doors", Kind: PartDoors, Quantity: 2, Weight: 40000, UnitCost: 65000}
+)
+
+// BOMLine 物料清单中的一行，同一料号的部件会合并，Quantity 为合并后的数量
+type BOMLine struct {
+	Part
+	LineWeight int64 `json:"total_weight_g"`
+	LineCost   int64 `json:"total_cost_cents"`
+}
+
+// BillOfMaterials 车辆的物料清单
+type BillOfMaterials struct {
+	Lines         []BOMLine `json:"lines"`
+	TotalQuantity int       `json:"total_quantity"`
+	TotalWeight   int64     `json:"total_weight_g"`
+	TotalCost     int64     `json:"total_cost_cents"`
+}
+
+// NewBillOfMaterials 按料号汇总车辆部件，行的顺序为料号第一次出现的顺序。
+// 同一料号的部件除数量外必须完全相同，否则返回 ErrPartConflict。
+func NewBillOfMaterials(vehicle Vehicle) (*BillOfMaterials, error) {
+	bom := &BillOfMaterials{}
+	index := make(map[string]int)
+	for _, part := range vehicle.Parts() {
+		i, ok := index[part.Number]
+		if !ok {
+			i = len(bom.Lines)
+			index[part.Number] = i
+			bom.Lines = append(bom.Lines, BOMLine{Part: part})
+			bom.Lines[i].Quantity = 0
+		}
+		line := &bom.Lines[i]
+		if first := line.Part; first.Name != part.Name || first.Kind != part.Kind ||
+			first.Weight != part.Weight || first.UnitCost != part.UnitCost {
+			return nil, fmt.Errorf("%w: %s is both %+v and %+v", ErrPartConflict, part.Number, first, part)
+		}
+		line.Quantity += part.Quantity
+		line.LineWeight += part.TotalWeight()
+		line.LineCost += part.TotalCost()
+
+		bom.TotalQuantity += part.Quantity
+		bom.TotalWeight += part.TotalWeight()
+		bom.TotalCost += part.TotalCost()
+	}
+	return bom, nil
+}
+
+var bomCSVHeader = []string{
+	"number", "name", "kind", "quantity",
+	"unit_weight_kg", "unit_cost", "total_weight_kg", "total_cost",
+}
+
+// WriteCSV 以 CSV 导出，重量以千克、金额以元为单位，最后一行为合计
+func (b *BillOfMaterials) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(bomCSVHeader); err != nil {
+		return err
+	}
+	for _, line := range b.Lines {
+		record := []string{
+			line.Number, line.Name, string(line.Kind), strconv.Itoa(line.Quantity),
+			formatKilograms(line.Weight), formatCents(line.UnitCost),
+			formatKilograms(line.LineWeight), formatCents(line.LineCost),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	total := []string{
+		"TOTAL", "", "", strconv.Itoa(b.TotalQuantity),
+		"", "", formatKilograms(b.TotalWeight), formatCents(b.TotalCost),
+	}
+	if err := cw.Write(total); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON 以 JSON 导出，重量以克、金额以分为单位
+func (b *BillOfMaterials) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(b)
+}
+
+func formatCents(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+func formatKilograms(grams int64) string {
+	sign := ""
+	if grams < 0 {
+		sign, grams = "-", -grams
+	}
+	return fmt.Sprintf("%s%d.%03d", sign, grams/1000, grams%1000)
+}
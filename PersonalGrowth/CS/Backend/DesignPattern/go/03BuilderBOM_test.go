@@ -0,0 +1,124 @@
+package designpattern
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestBillOfMaterials(t *testing.T) {
+	builder := &CarBuilder{}
+	(&Director{}).Build(builder)
+	car := builder.GetVehicle()
+	car.AddPart(carWheel) // 备胎与车轮同一料号，应合并到同一行
+
+	bom, err := NewBillOfMaterials(car)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bom.Lines) != 3 {
+		t.Fatalf("len(Lines) = %d, want 3", len(bom.Lines))
+	}
+	wheels := bom.Lines[0]
+	if wheels.Number != "CW-100" || wheels.Quantity != 8 {
+		t.Fatalf("wheels line = %+v, want CW-100 x8", wheels)
+	}
+	if wheels.LineCost != 8*8900 || wheels.LineWeight != 8*9500 {
+		t.Fatalf("wheels totals = %d cents, %d g", wheels.LineCost, wheels.LineWeight)
+	}
+
+	wantCost := int64(8*8900 + 350000 + 4*42000)
+	wantWeight := int64(8*9500 + 150000 + 4*25000)
+	if bom.TotalCost != wantCost || bom.TotalWeight != wantWeight || bom.TotalQuantity != 13 {
+		t.Fatalf("totals = %d cents, %d g, %d parts; want %d, %d, 13",
+			bom.TotalCost, bom.TotalWeight, bom.TotalQuantity, wantCost, wantWeight)
+	}
+}
+
+func TestBillOfMaterialsConflict(t *testing.T) {
+	builder := &CarBuilder{}
+	(&Director{}).Build(builder)
+	car := builder.GetVehicle()
+	cheap := carWheel
+	cheap.UnitCost = 5000
+	car.AddPart(cheap)
+
+	if _, err := NewBillOfMaterials(car); !errors.Is(err, ErrPartConflict) {
+		t.Fatalf("err = %v, want ErrPartConflict", err)
+	}
+}
+
+func TestBillOfMaterialsCSV(t *testing.T) {
+	builder := &TruckBuilder{}
+	(&Director{}).Build(builder)
+	bom, err := NewBillOfMaterials(builder.GetVehicle())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := bom.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		bomCSVHeader,
+		{"TW-100", "Truck Wheel", "wheels", "6", "45.000", "260.00", "270.000", "1560.00"},
+		{"TE-200", "Truck Engine", "engine", "1", "900.000", "12000.00", "900.000", "12000.00"},
+		{"TD-300", "Truck Doors", "doors", "2", "40.000", "650.00", "80.000", "1300.00"},
+		{"TOTAL", "", "", "9", "", "", "1250.000", "14860.00"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Fatalf("csv = %q, want %q", records, want)
+	}
+}
+
+func TestBillOfMaterialsJSON(t *testing.T) {
+	vehicle, err := NewRecipeDirector().BuildNamed("18-wheeler")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bom, err := NewBillOfMaterials(vehicle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := bom.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded BillOfMaterials
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&decoded, bom) {
+		t.Fatalf("round trip = %+v, want %+v", decoded, *bom)
+	}
+	if decoded.Lines[0].Quantity != 18 {
+		t.Fatalf("wheels quantity = %d, want 18", decoded.Lines[0].Quantity)
+	}
+}
+
+func TestFormatUnits(t *testing.T) {
+	cases := []struct {
+		got, want string
+	}{
+		{formatCents(0), "0.00"},
+		{formatCents(5), "0.05"},
+		{formatCents(123456), "1234.56"},
+		{formatCents(-250), "-2.50"},
+		{formatKilograms(1), "0.001"},
+		{formatKilograms(9500), "9.500"},
+	}
+	for _, tc := range cases {
+		if tc.got != tc.want {
+			t.Errorf("got %q, want %q", tc.got, tc.want)
+		}
+	}
+}
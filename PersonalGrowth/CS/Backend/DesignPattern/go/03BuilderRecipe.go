@@ -0,0 +1,279 @@
+package designpattern
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// 带校验的生成器
+// VehicleSpec 描述车型允许的部件，VehicleBuilder 按规则校验后才生成车辆，
+// Director 的配方以数据形式定义，可以从 JSON 加载。
+
+// PartKind 部件类型
+type PartKind string
+
+const (
+	PartWheels      PartKind = "wheels"
+	PartEngine      PartKind = "engine"
+	PartDoors       PartKind = "doors"
+	PartSpoiler     PartKind = "spoiler"
+	PartSunroof     PartKind = "sunroof"
+	PartCargoBed    PartKind = "cargo_bed"
+	PartSleeperCab  PartKind = "sleeper_cab"
+	PartFifthWheel  PartKind = "fifth_wheel"
+	PartTowingHitch PartKind = "towing_hitch"
+)
+
+var (
+	ErrMissingPart    = errors.New("missing required part")
+	ErrDuplicatePart  = errors.New("duplicate part")
+	ErrUnknownPart    = errors.New("part not allowed")
+	ErrInvalidPart    = errors.New("invalid part")
+	ErrPartConflict   = errors.New("conflicting parts share a part number")
+	ErrUnknownVehicle = errors.New("unknown vehicle")
+	ErrUnknownRecipe  = errors.New("unknown recipe")
+)
+
+// PartRule 车型对某类部件的要求，每类部件最多安装一个
+type PartRule struct {
+	Kind     PartKind
+	Required bool
+}
+
+// VehicleSpec 车型规格
+type VehicleSpec struct {
+	Name  string
+	New   func() Vehicle
+	Rules []PartRule
+}
+
+func (s VehicleSpec) rule(kind PartKind) (PartRule, bool) {
+	for _, rule := range s.Rules {
+		if rule.Kind == kind {
+			return rule, true
+		}
+	}
+	return PartRule{}, false
+}
+
+var (
+	CarSpec = VehicleSpec{
+		Name: "car",
+		New:  func() Vehicle { return &Car{} },
+		Rules: []PartRule{
+			{Kind: PartWheels, Required: true},
+			{Kind: PartEngine, Required: true},
+			{Kind: PartDoors, Required: true},
+			{Kind: PartSpoiler},
+			{Kind: PartSunroof},
+		},
+	}
+	TruckSpec = VehicleSpec{
+		Name: "truck",
+		New:  func() Vehicle { return &Truck{} },
+		Rules: []PartRule{
+			{Kind: PartWheels, Required: true},
+			{Kind: PartEngine, Required: true},
+			{Kind: PartDoors, Required: true},
+			{Kind: PartCargoBed},
+			{Kind: PartSleeperCab},
+			{Kind: PartFifthWheel},
+			{Kind: PartTowingHitch},
+		},
+	}
+)
+
+var vehicleSpecs = map[string]VehicleSpec{
+	CarSpec.Name:   CarSpec,
+	TruckSpec.Name: TruckSpec,
+}
+
+// LookupVehicleSpec 按名称查找车型规格
+func LookupVehicleSpec(name string) (VehicleSpec, error) {
+	spec, ok := vehicleSpecs[name]
+	if !ok {
+		return VehicleSpec{}, fmt.Errorf("%w: %q", ErrUnknownVehicle, name)
+	}
+	return spec, nil
+}
+
+// VehicleBuilder 流式生成器，部件在 Build 时统一校验
+type VehicleBuilder struct {
+	spec  VehicleSpec
+	parts []Part
+}
+
+func NewVehicleBuilder(spec VehicleSpec) *VehicleBuilder {
+	return &VehicleBuilder{spec: spec}
+}
+
+// Add 添加一个部件
+func (b *VehicleBuilder) Add(part Part) *VehicleBuilder {
+	b.parts = append(b.parts, part)
+	return b
+}
+
+func (b *VehicleBuilder) Wheels(part Part) *VehicleBuilder { return b.add(PartWheels, part) }
+func (b *VehicleBuilder) Engine(part Part) *VehicleBuilder { return b.add(PartEngine, part) }
+func (b *VehicleBuilder) Doors(part Part) *VehicleBuilder  { return b.add(PartDoors, part) }
+
+func (b *VehicleBuilder) add(kind PartKind, part Part) *VehicleBuilder {
+	part.Kind = kind
+	return b.Add(part)
+}
+
+// Validate 返回所有违反规则的问题，没有问题时返回 nil
+func (b *VehicleBuilder) Validate() error {
+	var errs []error
+	seen := make(map[PartKind]int)
+	for _, part := range b.parts {
+		if err := part.validate(); err != nil {
+			errs = append(errs, err)
+		}
+		if _, ok := b.spec.rule(part.Kind); !ok {
+			errs = append(errs, fmt.Errorf("%w: %s on %s", ErrUnknownPart, part.Kind, b.spec.Name))
+			continue
+		}
+		seen[part.Kind]++
+		if seen[part.Kind] == 2 {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrDuplicatePart, part.Kind))
+		}
+	}
+	for _, rule := range b.spec.Rules {
+		if rule.Required && seen[rule.Kind] == 0 {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrMissingPart, rule.Kind))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Build 校验部件并生成新的车辆，每次调用都返回独立的车辆
+func (b *VehicleBuilder) Build() (Vehicle, error) {
+	if err := b.Validate(); err != nil {
+		return nil, fmt.Errorf("build %s: %w", b.spec.Name, err)
+	}
+	vehicle := b.spec.New()
+	for _, part := range b.parts {
+		vehicle.AddPart(part)
+	}
+	return vehicle, nil
+}
+
+// Recipe 车辆配方，Vehicle 为车型规格名称
+type Recipe struct {
+	Name    string `json:"name"`
+	Vehicle string `json:"vehicle"`
+	Parts   []Part `json:"parts"`
+}
+
+// DefaultRecipes 内置配方
+var DefaultRecipes = []Recipe{
+	{
+		Name:    "sports-car",
+		Vehicle: "car",
+		Parts: []Part{
+			{Number: "SW-110", Name: "Performance Wheels", Kind: PartWheels, Quantity: 4, Weight: 11000, UnitCost: 32000},
+			{Number: "SE-210", Name: "V8 Engine", Kind: PartEngine, Quantity: 1, Weight: 210000, UnitCost: 1250000},
+			{Number: "SD-310", Name: "Two Doors", Kind: PartDoors, Quantity: 2, Weight: 22000, UnitCost: 58000},
+			{Number: "SS-410", Name: "Carbon Spoiler", Kind: PartSpoiler, Quantity: 1, Weight: 4500, UnitCost: 150000},
+		},
+	},
+	{
+		Name:    "pickup",
+		Vehicle: "truck",
+		Parts: []Part{
+			{Number: "PW-120", Name: "Off-road Wheels", Kind: PartWheels, Quantity: 4, Weight: 18000, UnitCost: 21000},
+			{Number: "PE-220", Name: "V6 Engine", Kind: PartEngine, Quantity: 1, Weight: 190000, UnitCost: 480000},
+			{Number: "PD-320", Name: "Four Doors", Kind: PartDoors, Quantity: 4, Weight: 27000, UnitCost: 45000},
+			{Number: "PC-420", Name: "Cargo Bed", Kind: PartCargoBed, Quantity: 1, Weight: 120000, UnitCost: 220000},
+			{Number: "PH-520", Name: "Towing Hitch", Kind: PartTowingHitch, Quantity: 1, Weight: 15000, UnitCost: 35000},
+		},
+	},
+	{
+		Name:    "18-wheeler",
+		Vehicle: "truck",
+		Parts: []Part{
+			{Number: "TW-130", Name: "Truck Wheel", Kind: PartWheels, Quantity: 18, Weight: 50000, UnitCost: 30000},
+			{Number: "TE-230", Name: "Diesel Engine", Kind: PartEngine, Quantity: 1, Weight: 1300000, UnitCost: 2500000},
+			{Number: "TD-330", Name: "Two Doors", Kind: PartDoors, Quantity: 2, Weight: 45000, UnitCost: 70000},
+			{Number: "TS-430", Name: "Sleeper Cab", Kind: PartSleeperCab, Quantity: 1, Weight: 400000, UnitCost: 900000},
+			{Number: "TF-530", Name: "Fifth Wheel Coupling", Kind: PartFifthWheel, Quantity: 1, Weight: 160000, UnitCost: 310000},
+		},
+	},
+}
+
+// LoadRecipes 从 JSON 数组加载配方
+func LoadRecipes(r io.Reader) ([]Recipe, error) {
+	var recipes []Recipe
+	if err := json.NewDecoder(r).Decode(&recipes); err != nil {
+		return nil, fmt.Errorf("load recipes: %w", err)
+	}
+	return recipes, nil
+}
+
+// NewRecipeDirector 返回使用指定配方的指挥者，recipes 为空时使用 DefaultRecipes
+func NewRecipeDirector(recipes ...Recipe) *Director {
+	if len(recipes) == 0 {
+		recipes = DefaultRecipes
+	}
+	d := &Director{recipes: make(map[string]Recipe, len(recipes))}
+	for _, recipe := range recipes {
+		d.recipes[recipe.Name] = recipe
+	}
+	return d
+}
+
+// Recipes 返回已知配方名称
+func (d *Director) Recipes() []string {
+	names := make([]string, 0, len(d.recipes))
+	for name := range d.recipes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BuildNamed 按名称使用配方生成车辆
+func (d *Director) BuildNamed(name string) (Vehicle, error) {
+	recipe, ok := d.recipes[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownRecipe, name)
+	}
+	return d.BuildRecipe(recipe)
+}
+
+// BuildRecipe 按配方生成车辆
+func (d *Director) BuildRecipe(recipe Recipe) (Vehicle, error) {
+	builder, err := recipeBuilder(recipe)
+	if err != nil {
+		return nil, err
+	}
+	return builder.Build()
+}
+
+// ValidateRecipe 只校验配方不生成车辆，用于在下单前检查客户订单
+func (d *Director) ValidateRecipe(recipe Recipe) error {
+	builder, err := recipeBuilder(recipe)
+	if err != nil {
+		return err
+	}
+	if err := builder.Validate(); err != nil {
+		return fmt.Errorf("recipe %s: %w", recipe.Name, err)
+	}
+	return nil
+}
+
+func recipeBuilder(recipe Recipe) (*VehicleBuilder, error) {
+	spec, err := LookupVehicleSpec(recipe.Vehicle)
+	if err != nil {
+		return nil, fmt.Errorf("recipe %s: %w", recipe.Name, err)
+	}
+	builder := NewVehicleBuilder(spec)
+	for _, part := range recipe.Parts {
+		builder.Add(part)
+	}
+	return builder, nil
+}
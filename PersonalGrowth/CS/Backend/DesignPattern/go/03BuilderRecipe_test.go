@@ -0,0 +1,128 @@
+package designpattern
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestVehicleBuilder(t *testing.T) {
+	builder := NewVehicleBuilder(CarSpec).
+		Wheels(carWheel).
+		Engine(carEngine).
+		Doors(carDoors).
+		Add(Part{Number: "CR-400", Name: "Glass Sunroof", Kind: PartSunroof, Quantity: 1})
+	vehicle, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := vehicle.(*Car); !ok {
+		t.Fatalf("vehicle = %T, want *Car", vehicle)
+	}
+	if want := []string{"Car Wheel", "Car Engine", "Car Doors", "Glass Sunroof"}; !reflect.DeepEqual(partNames(vehicle), want) {
+		t.Fatalf("parts = %v, want %v", partNames(vehicle), want)
+	}
+
+	again, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	again.AddPart(carWheel)
+	if len(vehicle.Parts()) != 4 {
+		t.Fatal("Build should return independent vehicles")
+	}
+}
+
+func TestVehicleBuilderValidation(t *testing.T) {
+	_, err := NewVehicleBuilder(CarSpec).
+		Wheels(carWheel).
+		Engine(carEngine).
+		Engine(carEngine).
+		Add(Part{Number: "PC-420", Name: "Cargo Bed", Kind: PartCargoBed, Quantity: 1}).
+		Add(Part{Number: "CR-400", Name: "Sunroof", Kind: PartSunroof}).
+		Build()
+	for _, want := range []error{ErrMissingPart, ErrDuplicatePart, ErrUnknownPart, ErrInvalidPart} {
+		if !errors.Is(err, want) {
+			t.Errorf("err = %v, want %v", err, want)
+		}
+	}
+	if err != nil && !strings.Contains(err.Error(), "doors") {
+		t.Errorf("err = %v, want it to name the missing doors", err)
+	}
+
+	vehicle, err := NewVehicleBuilder(TruckSpec).Build()
+	if !errors.Is(err, ErrMissingPart) || vehicle != nil {
+		t.Fatalf("empty truck = %v, %v", vehicle, err)
+	}
+}
+
+func TestDirectorRecipes(t *testing.T) {
+	director := NewRecipeDirector()
+	if want := []string{"18-wheeler", "pickup", "sports-car"}; !reflect.DeepEqual(director.Recipes(), want) {
+		t.Fatalf("Recipes() = %v, want %v", director.Recipes(), want)
+	}
+
+	cases := map[string]struct {
+		vehicle Vehicle
+		part    string
+	}{
+		"sports-car": {&Car{}, "Carbon Spoiler"},
+		"pickup":     {&Truck{}, "Cargo Bed"},
+		"18-wheeler": {&Truck{}, "Sleeper Cab"},
+	}
+	for name, tc := range cases {
+		vehicle, err := director.BuildNamed(name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if reflect.TypeOf(vehicle) != reflect.TypeOf(tc.vehicle) {
+			t.Fatalf("%s: vehicle = %T, want %T", name, vehicle, tc.vehicle)
+		}
+		found := false
+		for _, part := range partNames(vehicle) {
+			found = found || part == tc.part
+		}
+		if !found {
+			t.Fatalf("%s: parts %v missing %q", name, partNames(vehicle), tc.part)
+		}
+	}
+
+	if _, err := director.BuildNamed("limousine"); !errors.Is(err, ErrUnknownRecipe) {
+		t.Fatalf("err = %v, want ErrUnknownRecipe", err)
+	}
+}
+
+func TestLoadRecipes(t *testing.T) {
+	recipes, err := LoadRecipes(strings.NewReader(`[
+		{"name": "hatchback", "vehicle": "car", "parts": [
+			{"number": "HW-100", "kind": "wheels", "name": "Steel Wheels", "quantity": 4},
+			{"number": "HE-200", "kind": "engine", "name": "I4 Engine", "quantity": 1},
+			{"number": "HD-300", "kind": "doors", "name": "Five Doors", "quantity": 5}
+		]},
+		{"name": "broken", "vehicle": "car", "parts": [
+			{"number": "HW-100", "kind": "wheels", "name": "Steel Wheels", "quantity": 4},
+			{"number": "TF-530", "kind": "fifth_wheel", "name": "Coupling", "quantity": 1}
+		]},
+		{"name": "boat", "vehicle": "boat", "parts": []}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	director := NewRecipeDirector(recipes...)
+
+	if err := director.ValidateRecipe(recipes[0]); err != nil {
+		t.Fatal(err)
+	}
+	err = director.ValidateRecipe(recipes[1])
+	if !errors.Is(err, ErrMissingPart) || !errors.Is(err, ErrUnknownPart) {
+		t.Fatalf("broken err = %v", err)
+	}
+	if _, err := director.BuildNamed("boat"); !errors.Is(err, ErrUnknownVehicle) {
+		t.Fatalf("boat err = %v, want ErrUnknownVehicle", err)
+	}
+
+	if _, err := LoadRecipes(strings.NewReader(`{`)); err == nil {
+		t.Fatal("LoadRecipes accepted invalid JSON")
+	}
+}
@@ -1,10 +1,18 @@
 package designpattern
 
 import (
-	"fmt"
+	"reflect"
 	"testing"
 )
 
+func partNames(vehicle Vehicle) []string {
+	var names []string
+	for _, part := range vehicle.Parts() {
+		names = append(names, part.Name)
+	}
+	return names
+}
+
 func TestBuilder(t *testing.T) {
 	director := &Director{}
 
@@ -12,11 +20,15 @@ func TestBuilder(t *testing.T) {
 	carBuilder := &CarBuilder{}
 	director.Build(carBuilder)
 	car := carBuilder.GetVehicle()
-	fmt.Println(car)
+	if want := []string{"Car Wheel", "Car Engine", "Car Doors"}; !reflect.DeepEqual(partNames(car), want) {
+		t.Fatalf("car parts = %v, want %v", partNames(car), want)
+	}
 
 	// 构建卡车
 	truckBuilder := &TruckBuilder{}
 	director.Build(truckBuilder)
 	truck := truckBuilder.GetVehicle()
-	fmt.Println(truck)
+	if want := []string{"Truck Wheel", "Truck Engine", "Truck Doors"}; !reflect.DeepEqual(partNames(truck), want) {
+		t.Fatalf("truck parts = %v, want %v", partNames(truck), want)
+	}
 }
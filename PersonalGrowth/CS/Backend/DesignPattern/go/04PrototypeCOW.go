@@ -0,0 +1,142 @@
+package designpattern
+
+import (
+	"encoding/json"
+	"sort"
+	"sync/atomic"
+)
+
+// 写时复制原型
+// Polyline 的点列表和样式表放在共享的 polylineData 中，Clone 只增加引用计数，
+// 第一次修改时才复制数据。引用计数只增不减（被回收的克隆不会归还计数），
+// 因此最坏情况下会多复制一次，但不会出现两个形状同时修改同一份数据。
+//
+// 与其它形状一样，同一个 Polyline 不能在多个 goroutine 中同时读写；
+// 不同的克隆之间可以并发读写。
+
+// Point 二维坐标点
+type Point struct {
+	X, Y float64
+}
+
+type polylineData struct {
+	refs   atomic.Int32
+	points []Point
+	styles map[string]string
+}
+
+func newPolylineData(points []Point, styles map[string]string) *polylineData {
+	data := &polylineData{
+		points: append([]Point(nil), points...),
+		styles: make(map[string]string, len(styles)),
+	}
+	for k, v := range styles {
+		data.styles[k] = v
+	}
+	data.refs.Store(1)
+	return data
+}
+
+// Polyline 带有大量点和样式的折线
+type Polyline struct {
+	BaseShape
+	data *polylineData
+}
+
+// NewPolyline 创建折线，points 和 styles 会被复制
+func NewPolyline(points []Point, styles map[string]string) *Polyline {
+	return &Polyline{
+		BaseShape: BaseShape{typ: "Polyline"},
+		data:      newPolylineData(points, styles),
+	}
+}
+
+// Clone 返回与 p 共享数据的副本
+func (p *Polyline) Clone() Shape {
+	p.data.refs.Add(1)
+	return &Polyline{BaseShape: p.BaseShape, data: p.data}
+}
+
+// EagerClone 立即复制全部数据，用于对比
+func (p *Polyline) EagerClone() *Polyline {
+	return &Polyline{BaseShape: p.BaseShape, data: newPolylineData(p.data.points, p.data.styles)}
+}
+
+// mutable 在修改前调用，数据被共享时复制一份
+func (p *Polyline) mutable() *polylineData {
+	if p.data.refs.Load() > 1 {
+		shared := p.data
+		p.data = newPolylineData(shared.points, shared.styles)
+		shared.refs.Add(-1)
+	}
+	return p.data
+}
+
+func (p *Polyline) NumPoints() int {
+	return len(p.data.points)
+}
+
+func (p *Polyline) Point(i int) Point {
+	return p.data.points[i]
+}
+
+// Points 返回点列表的副本
+func (p *Polyline) Points() []Point {
+	return append([]Point(nil), p.data.points...)
+}
+
+func (p *Polyline) Style(name string) (string, bool) {
+	value, ok := p.data.styles[name]
+	return value, ok
+}
+
+// StyleNames 返回样式名称，按字母排序
+func (p *Polyline) StyleNames() []string {
+	names := make([]string, 0, len(p.data.styles))
+	for name := range p.data.styles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (p *Polyline) SetPoint(i int, point Point) {
+	p.mutable().points[i] = point
+}
+
+func (p *Polyline) AppendPoint(point Point) {
+	data := p.mutable()
+	data.points = append(data.points, point)
+}
+
+func (p *Polyline) SetStyle(name, value string) {
+	p.mutable().styles[name] = value
+}
+
+func (p *Polyline) DeleteStyle(name string) {
+	delete(p.mutable().styles, name)
+}
+
+// polylineState 是 Polyline 持久化的字段
+type polylineState struct {
+	Points []Point           `json:"points"`
+	Styles map[string]string `json:"styles,omitempty"`
+}
+
+// MarshalState 实现 StatefulShape
+func (p *Polyline) MarshalState() (json.RawMessage, error) {
+	return json.Marshal(polylineState{Points: p.data.points, Styles: p.data.styles})
+}
+
+// UnmarshalState 实现 StatefulShape，替换 p 的数据，不影响共享数据的克隆
+func (p *Polyline) UnmarshalState(state json.RawMessage) error {
+	var s polylineState
+	if err := json.Unmarshal(state, &s); err != nil {
+		return err
+	}
+	if p.data != nil {
+		p.data.refs.Add(-1)
+	}
+	p.data = newPolylineData(s.Points, s.Styles)
+	return nil
+}
@@ -0,0 +1,195 @@
+package designpattern
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func newTestPolyline(n int) *Polyline {
+	points := make([]Point, n)
+	for i := range points {
+		points[i] = Point{X: float64(i), Y: float64(i * i)}
+	}
+	styles := map[string]string{"stroke": "black", "width": "2"}
+	for i := 0; i < n/100; i++ {
+		styles[fmt.Sprintf("layer-%d", i)] = "visible"
+	}
+	return NewPolyline(points, styles)
+}
+
+func TestPolylineCopyOnWrite(t *testing.T) {
+	proto := newTestPolyline(10)
+	proto.SetId("route")
+	clone := proto.Clone().(*Polyline)
+
+	if clone.data != proto.data {
+		t.Fatal("clone should share data before the first write")
+	}
+	if clone.GetId() != "route" || clone.GetType() != "Polyline" {
+		t.Fatalf("clone = %s/%s", clone.GetType(), clone.GetId())
+	}
+
+	clone.SetPoint(0, Point{X: -1, Y: -1})
+	clone.SetStyle("stroke", "red")
+	if clone.data == proto.data {
+		t.Fatal("clone still shares data after a write")
+	}
+	if proto.Point(0) != (Point{}) {
+		t.Fatalf("prototype point = %v, want origin", proto.Point(0))
+	}
+	if stroke, _ := proto.Style("stroke"); stroke != "black" {
+		t.Fatalf("prototype stroke = %q, want black", stroke)
+	}
+
+	// 原型修改也不能影响已有的克隆
+	other := proto.Clone().(*Polyline)
+	proto.AppendPoint(Point{X: 100, Y: 100})
+	proto.DeleteStyle("width")
+	if other.NumPoints() != 10 {
+		t.Fatalf("clone has %d points, want 10", other.NumPoints())
+	}
+	if _, ok := other.Style("width"); !ok {
+		t.Fatal("clone lost the width style")
+	}
+	if proto.NumPoints() != 11 || !reflect.DeepEqual(proto.StyleNames(), []string{"stroke"}) {
+		t.Fatalf("prototype = %d points, styles %v", proto.NumPoints(), proto.StyleNames())
+	}
+
+	// 唯一持有者直接修改，不再复制
+	data := clone.data
+	clone.SetPoint(1, Point{X: 7})
+	if clone.data != data {
+		t.Fatal("sole owner copied its data")
+	}
+}
+
+func TestPolylineEagerClone(t *testing.T) {
+	proto := newTestPolyline(5)
+	eager := proto.EagerClone()
+	if eager.data == proto.data || !reflect.DeepEqual(eager.Points(), proto.Points()) {
+		t.Fatal("EagerClone should copy the data")
+	}
+	points := eager.Points()
+	points[0] = Point{X: 42}
+	if eager.Point(0) == points[0] {
+		t.Fatal("Points() returned the internal slice")
+	}
+}
+
+func TestPolylineConcurrentClones(t *testing.T) {
+	cache := NewShapeCache()
+	proto := newTestPolyline(1000)
+	proto.SetId("route")
+	if err := cache.Register(proto); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				shape, err := cache.GetShape("route")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				line := shape.(*Polyline)
+				if got := line.Point(999); got != (Point{X: 999, Y: 999 * 999}) {
+					t.Errorf("Point(999) = %v", got)
+					return
+				}
+				if i%2 == 0 {
+					line.SetPoint(999, Point{X: float64(i)})
+					line.SetStyle("stroke", fmt.Sprint(i))
+					if got := line.Point(999); got.X != float64(i) {
+						t.Errorf("own write lost: %v", got)
+						return
+					}
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	shape, _ := cache.GetShape("route")
+	if stroke, _ := shape.(*Polyline).Style("stroke"); stroke != "black" {
+		t.Fatalf("cached prototype stroke = %q, want black", stroke)
+	}
+}
+
+var polylineSink *Polyline
+
+func benchmarkPolylineClone(b *testing.B, clone func(*Polyline) *Polyline, mutate bool) {
+	proto := newTestPolyline(10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		line := clone(proto)
+		if mutate {
+			line.SetPoint(0, Point{X: 1})
+		}
+		polylineSink = line
+	}
+}
+
+func TestPolylinePersist(t *testing.T) {
+	proto := newTestPolyline(300)
+	proto.SetId("route")
+	cache := NewShapeCache()
+	if err := cache.Register(proto); err != nil {
+		t.Fatal(err)
+	}
+	codec := NewShapeCacheCodec()
+	for _, format := range []ShapeFormat{ShapeFormatJSON, ShapeFormatGob} {
+		var buf bytes.Buffer
+		if err := codec.Encode(&buf, cache, format); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := codec.Decode(&buf, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shape, err := loaded.GetShape("route")
+		if err != nil {
+			t.Fatal(err)
+		}
+		route := shape.(*Polyline)
+		if !reflect.DeepEqual(route.Points(), proto.Points()) || !reflect.DeepEqual(route.StyleNames(), proto.StyleNames()) {
+			t.Fatalf("%v: loaded %d points, styles %v", format, route.NumPoints(), route.StyleNames())
+		}
+	}
+}
+
+func eagerPolylineClone(p *Polyline) *Polyline { return p.EagerClone() }
+func cowPolylineClone(p *Polyline) *Polyline   { return p.Clone().(*Polyline) }
+
+func BenchmarkPolylineCloneEager(b *testing.B) {
+	benchmarkPolylineClone(b, eagerPolylineClone, false)
+}
+
+func BenchmarkPolylineCloneCOW(b *testing.B) {
+	benchmarkPolylineClone(b, cowPolylineClone, false)
+}
+
+func BenchmarkPolylineCloneEagerThenWrite(b *testing.B) {
+	benchmarkPolylineClone(b, eagerPolylineClone, true)
+}
+
+func BenchmarkPolylineCloneCOWThenWrite(b *testing.B) {
+	benchmarkPolylineClone(b, cowPolylineClone, true)
+}
+
+// 04Prototypem.go 中的形状通过 DeepClone 复制，作为基准
+func BenchmarkShapeDeepClone(b *testing.B) {
+	circle := NewCircle()
+	circle.SetId(CircleId)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = circle.Clone()
+	}
+}
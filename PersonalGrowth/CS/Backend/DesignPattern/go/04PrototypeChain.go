@@ -0,0 +1,164 @@
+package designpattern
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// 原型链
+// 类似 JavaScript 的对象：读取属性时先查自身，找不到再沿原型链向上查找；
+// 写入和删除只作用于对象自身的属性，因此子对象可以覆盖父对象的默认值而不影响父对象。
+
+var (
+	ErrPropertyNotFound = errors.New("property not found")
+	ErrNotCallable      = errors.New("property is not a method")
+	ErrPrototypeCycle   = errors.New("prototype chain cycle")
+)
+
+// Method 作为属性值时可以通过 Call 调用，this 是调用时的接收者，而不是定义方法的对象
+type Method func(this *Object, args ...interface{}) (interface{}, error)
+
+// protoMu 保护所有对象的 proto 字段，保证 SetProto 的环检测和修改是原子的
+var protoMu sync.RWMutex
+
+// Object 带原型链的动态对象，可并发使用
+type Object struct {
+	mu    sync.RWMutex
+	props map[string]interface{}
+	proto *Object
+}
+
+// NewObject 以 proto 为原型创建对象，相当于 Object.create(proto)，proto 可以为 nil
+func NewObject(proto *Object) *Object {
+	return &Object{props: make(map[string]interface{}), proto: proto}
+}
+
+// Proto 返回原型
+func (o *Object) Proto() *Object {
+	protoMu.RLock()
+	defer protoMu.RUnlock()
+	return o.proto
+}
+
+// SetProto 修改原型，会形成环时返回 ErrPrototypeCycle
+func (o *Object) SetProto(proto *Object) error {
+	protoMu.Lock()
+	defer protoMu.Unlock()
+	for p := proto; p != nil; p = p.proto {
+		if p == o {
+			return ErrPrototypeCycle
+		}
+	}
+	o.proto = proto
+	return nil
+}
+
+func (o *Object) own(name string) (interface{}, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	value, ok := o.props[name]
+	return value, ok
+}
+
+// Lookup 沿原型链查找属性，返回属性值和定义它的对象
+func (o *Object) Lookup(name string) (value interface{}, owner *Object, ok bool) {
+	protoMu.RLock()
+	defer protoMu.RUnlock()
+	for p := o; p != nil; p = p.proto {
+		if value, ok := p.own(name); ok {
+			return value, p, true
+		}
+	}
+	return nil, nil, false
+}
+
+// Get 沿原型链读取属性
+func (o *Object) Get(name string) (interface{}, bool) {
+	value, _, ok := o.Lookup(name)
+	return value, ok
+}
+
+// Set 设置自身属性，原型上的同名属性会被覆盖
+func (o *Object) Set(name string, value interface{}) *Object {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.props[name] = value
+	return o
+}
+
+// Delete 删除自身属性，返回之前是否存在；原型上的同名属性随后重新可见
+func (o *Object) Delete(name string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	_, ok := o.props[name]
+	delete(o.props, name)
+	return ok
+}
+
+// HasOwn 判断属性是否定义在对象自身
+func (o *Object) HasOwn(name string) bool {
+	_, ok := o.own(name)
+	return ok
+}
+
+// Has 判断属性是否定义在对象自身或原型链上
+func (o *Object) Has(name string) bool {
+	_, _, ok := o.Lookup(name)
+	return ok
+}
+
+// OwnKeys 返回自身属性名，按字母排序
+func (o *Object) OwnKeys() []string {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	keys := make([]string, 0, len(o.props))
+	for key := range o.props {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Keys 返回自身和原型链上的所有属性名，去重后按字母排序
+func (o *Object) Keys() []string {
+	protoMu.RLock()
+	defer protoMu.RUnlock()
+	seen := make(map[string]bool)
+	var keys []string
+	for p := o; p != nil; p = p.proto {
+		for _, key := range p.OwnKeys() {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Call 查找方法并以 o 作为 this 调用
+func (o *Object) Call(name string, args ...interface{}) (interface{}, error) {
+	value, ok := o.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPropertyNotFound, name)
+	}
+	method, ok := value.(Method)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is %T", ErrNotCallable, name, value)
+	}
+	return method(o, args...)
+}
+
+// Clone 复制自身属性（浅拷贝），副本与 o 使用同一个原型
+func (o *Object) Clone() *Object {
+	clone := NewObject(o.Proto())
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	for key, value := range o.props {
+		clone.props[key] = value
+	}
+	return clone
+}
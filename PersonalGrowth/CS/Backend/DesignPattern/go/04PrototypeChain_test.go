@@ -0,0 +1,137 @@
+package designpattern
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// 形状模板：shape 提供默认值和方法，circle 继承 shape，实例再继承 circle
+func newShapeTemplates() (shape, circle *Object) {
+	shape = NewObject(nil).
+		Set("type", "Shape").
+		Set("stroke", "black").
+		Set("describe", Method(func(this *Object, args ...interface{}) (interface{}, error) {
+			typ, _ := this.Get("type")
+			stroke, _ := this.Get("stroke")
+			return typ.(string) + "/" + stroke.(string), nil
+		}))
+	circle = NewObject(shape).
+		Set("type", "Circle").
+		Set("radius", 1.0).
+		Set("area", Method(func(this *Object, args ...interface{}) (interface{}, error) {
+			r, _ := this.Get("radius")
+			return math.Pi * r.(float64) * r.(float64), nil
+		}))
+	return shape, circle
+}
+
+func TestObjectPrototypeChain(t *testing.T) {
+	shape, circle := newShapeTemplates()
+	c := NewObject(circle).Set("radius", 2.0)
+
+	if got, _ := c.Get("stroke"); got != "black" {
+		t.Fatalf("stroke = %v, want inherited black", got)
+	}
+	_, owner, _ := c.Lookup("stroke")
+	if owner != shape {
+		t.Fatal("stroke should be found on the shape template")
+	}
+	if !c.HasOwn("radius") || c.HasOwn("stroke") || !c.Has("stroke") {
+		t.Fatal("HasOwn/Has mismatch")
+	}
+
+	// 方法的 this 是接收者
+	area, err := c.Call("area")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if area.(float64) != math.Pi*4 {
+		t.Fatalf("area = %v, want 4π", area)
+	}
+	desc, _ := c.Call("describe")
+	if desc != "Circle/black" {
+		t.Fatalf("describe = %v, want Circle/black", desc)
+	}
+
+	// 修改原型对所有继承者可见，覆盖只影响自身
+	shape.Set("stroke", "blue")
+	c.Set("stroke", "red")
+	other := NewObject(circle)
+	if got, _ := other.Get("stroke"); got != "blue" {
+		t.Fatalf("other stroke = %v, want blue", got)
+	}
+	if !c.Delete("stroke") || c.Delete("stroke") {
+		t.Fatal("Delete should remove own property once")
+	}
+	if got, _ := c.Get("stroke"); got != "blue" {
+		t.Fatalf("stroke after delete = %v, want blue", got)
+	}
+
+	if want := []string{"radius"}; !reflect.DeepEqual(c.OwnKeys(), want) {
+		t.Fatalf("OwnKeys() = %v, want %v", c.OwnKeys(), want)
+	}
+	if want := []string{"area", "describe", "radius", "stroke", "type"}; !reflect.DeepEqual(c.Keys(), want) {
+		t.Fatalf("Keys() = %v, want %v", c.Keys(), want)
+	}
+}
+
+func TestObjectCallErrors(t *testing.T) {
+	_, circle := newShapeTemplates()
+	if _, err := circle.Call("missing"); !errors.Is(err, ErrPropertyNotFound) {
+		t.Fatalf("err = %v, want ErrPropertyNotFound", err)
+	}
+	if _, err := circle.Call("radius"); !errors.Is(err, ErrNotCallable) {
+		t.Fatalf("err = %v, want ErrNotCallable", err)
+	}
+}
+
+func TestObjectSetProto(t *testing.T) {
+	shape, circle := newShapeTemplates()
+	if err := shape.SetProto(circle); !errors.Is(err, ErrPrototypeCycle) {
+		t.Fatalf("err = %v, want ErrPrototypeCycle", err)
+	}
+	if err := shape.SetProto(shape); !errors.Is(err, ErrPrototypeCycle) {
+		t.Fatalf("self err = %v, want ErrPrototypeCycle", err)
+	}
+
+	defaults := NewObject(nil).Set("fill", "none")
+	if err := shape.SetProto(defaults); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := circle.Get("fill"); got != "none" {
+		t.Fatalf("fill = %v, want none", got)
+	}
+
+	clone := circle.Clone()
+	clone.Set("radius", 5.0)
+	if clone.Proto() != shape {
+		t.Fatal("clone should keep the prototype")
+	}
+	if r, _ := circle.Get("radius"); r != 1.0 {
+		t.Fatalf("circle radius = %v, want 1", r)
+	}
+}
+
+func TestObjectConcurrent(t *testing.T) {
+	shape, circle := newShapeTemplates()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c := NewObject(circle).Set("radius", float64(i))
+			if _, err := c.Call("area"); err != nil {
+				t.Error(err)
+			}
+			shape.Set("stroke", "gray")
+			c.Keys()
+			if i%5 == 0 {
+				_ = circle.SetProto(shape)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
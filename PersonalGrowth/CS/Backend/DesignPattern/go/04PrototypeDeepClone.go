@@ -0,0 +1,139 @@
+package designpattern
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// 基于反射的深拷贝
+// DeepClone 递归复制结构体、切片、数组、map、指针和接口，包括未导出字段。
+// 同一个指针、map 或切片在副本中仍然指向同一个新对象，因此循环引用和别名关系都会被保留。
+// 函数、channel 和 unsafe.Pointer 不会被复制，副本与原对象共享。
+//
+// 结构体字段可以用 clone 标签控制复制方式：
+//
+//	clone:"shared" 副本与原对象共享该字段的值（浅拷贝）
+//	clone:"-"      副本中该字段为零值
+//
+// 注意：指向结构体内部字段的指针会被复制为独立的对象，而不是指向副本中的对应字段。
+
+const cloneTag = "clone"
+
+// DeepClone 返回 src 的深拷贝
+func DeepClone[T any](src T) T {
+	v := reflect.ValueOf(&src).Elem()
+	c := &cloner{seen: make(map[cloneKey]reflect.Value)}
+	return c.clone(v).Interface().(T)
+}
+
+type cloneKey struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+type cloner struct {
+	seen map[cloneKey]reflect.Value
+}
+
+func (c *cloner) clone(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		key := cloneKey{ptr: v.Pointer(), typ: v.Type()}
+		if dst, ok := c.seen[key]; ok {
+			return dst
+		}
+		dst := reflect.New(v.Type().Elem())
+		c.seen[key] = dst
+		dst.Elem().Set(c.clone(v.Elem()))
+		return dst
+
+	case reflect.Map:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		key := cloneKey{ptr: v.Pointer(), typ: v.Type()}
+		if dst, ok := c.seen[key]; ok {
+			return dst
+		}
+		dst := reflect.MakeMapWithSize(v.Type(), v.Len())
+		c.seen[key] = dst
+		iter := v.MapRange()
+		for iter.Next() {
+			dst.SetMapIndex(c.clone(iter.Key()), c.clone(iter.Value()))
+		}
+		return dst
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		key := cloneKey{ptr: v.Pointer(), typ: v.Type(), len: v.Len()}
+		if dst, ok := c.seen[key]; ok {
+			return dst
+		}
+		dst := reflect.MakeSlice(v.Type(), v.Len(), v.Cap())
+		c.seen[key] = dst
+		for i := 0; i < v.Len(); i++ {
+			dst.Index(i).Set(c.clone(v.Index(i)))
+		}
+		return dst
+
+	case reflect.Array:
+		dst := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			dst.Index(i).Set(c.clone(v.Index(i)))
+		}
+		return dst
+
+	case reflect.Interface:
+		dst := reflect.New(v.Type()).Elem()
+		if !v.IsNil() {
+			dst.Set(c.clone(v.Elem()))
+		}
+		return dst
+
+	case reflect.Struct:
+		src := addressable(v)
+		dst := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			switch field.Tag.Get(cloneTag) {
+			case "-":
+				continue
+			case "shared":
+				writable(dst.Field(i)).Set(writable(src.Field(i)))
+			default:
+				writable(dst.Field(i)).Set(c.clone(writable(src.Field(i))))
+			}
+		}
+		return dst
+
+	default:
+		// 基本类型按值复制，函数、channel 和 unsafe.Pointer 直接共享
+		dst := reflect.New(v.Type()).Elem()
+		dst.Set(v)
+		return dst
+	}
+}
+
+// addressable 返回可以取地址的 v，必要时复制一份
+func addressable(v reflect.Value) reflect.Value {
+	if v.CanAddr() {
+		return v
+	}
+	dst := reflect.New(v.Type()).Elem()
+	dst.Set(v)
+	return dst
+}
+
+// writable 让未导出字段可以读写，v 必须可以取地址
+func writable(v reflect.Value) reflect.Value {
+	if v.CanSet() {
+		return v
+	}
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
@@ -0,0 +1,137 @@
+package designpattern
+
+import (
+	"reflect"
+	"testing"
+)
+
+type point struct{ X, Y int }
+
+// polygon 是带有嵌套数据的自定义形状
+type polygon struct {
+	BaseShape
+	points   []point
+	labels   map[string]*point
+	origin   *point
+	style    interface{}
+	palette  *[]string `clone:"shared"`
+	scratch  []byte    `clone:"-"`
+	callback func() int
+}
+
+func (p *polygon) Clone() Shape {
+	return DeepClone(p)
+}
+
+func TestDeepCloneShape(t *testing.T) {
+	origin := &point{1, 1}
+	palette := []string{"red"}
+	src := &polygon{
+		BaseShape: BaseShape{id: "tri", typ: "Polygon"},
+		points:    []point{{0, 0}, {4, 0}, {0, 3}},
+		labels:    map[string]*point{"origin": origin},
+		origin:    origin,
+		style:     map[string]int{"width": 2},
+		palette:   &palette,
+		scratch:   []byte("tmp"),
+		callback:  func() int { return 7 },
+	}
+
+	dst := src.Clone().(*polygon)
+	if dst.GetId() != "tri" || dst.GetType() != "Polygon" {
+		t.Fatalf("clone = %s/%s", dst.GetType(), dst.GetId())
+	}
+	if !reflect.DeepEqual(dst.points, src.points) || &dst.points[0] == &src.points[0] {
+		t.Fatal("points not deep copied")
+	}
+	if dst.origin == src.origin || *dst.origin != *src.origin {
+		t.Fatal("origin not deep copied")
+	}
+	// 别名关系保留：labels["origin"] 和 origin 仍然指向同一个对象
+	if dst.labels["origin"] != dst.origin {
+		t.Fatal("aliasing between labels and origin lost")
+	}
+	style := dst.style.(map[string]int)
+	style["width"] = 5
+	if src.style.(map[string]int)["width"] != 2 {
+		t.Fatal("interface value shares map with source")
+	}
+	if dst.palette != src.palette {
+		t.Fatal(`clone:"shared" field was copied`)
+	}
+	if dst.scratch != nil {
+		t.Fatalf(`clone:"-" field = %q, want nil`, dst.scratch)
+	}
+	if dst.callback() != 7 {
+		t.Fatal("func field not shared")
+	}
+
+	dst.points[0].X = 100
+	dst.origin.X = 100
+	if src.points[0].X != 0 || src.origin.X != 1 {
+		t.Fatal("modifying the clone changed the source")
+	}
+}
+
+type ringNode struct {
+	Value int
+	Next  *ringNode
+	Peers []*ringNode
+}
+
+func TestDeepCloneCycles(t *testing.T) {
+	a := &ringNode{Value: 1}
+	b := &ringNode{Value: 2, Next: a}
+	a.Next = b
+	a.Peers = []*ringNode{a, b}
+
+	c := DeepClone(a)
+	if c == a || c.Next == b {
+		t.Fatal("nodes not copied")
+	}
+	if c.Next.Next != c {
+		t.Fatal("cycle not preserved")
+	}
+	if c.Peers[0] != c || c.Peers[1] != c.Next {
+		t.Fatal("aliasing in slice not preserved")
+	}
+
+	m := map[string]interface{}{}
+	m["self"] = m
+	mc := DeepClone(m)
+	if reflect.ValueOf(mc["self"]).Pointer() != reflect.ValueOf(mc).Pointer() {
+		t.Fatal("self-referencing map not preserved")
+	}
+}
+
+func TestDeepCloneValues(t *testing.T) {
+	if got := DeepClone(42); got != 42 {
+		t.Fatalf("DeepClone(42) = %d", got)
+	}
+	if got := DeepClone[*point](nil); got != nil {
+		t.Fatalf("DeepClone(nil) = %v", got)
+	}
+
+	arr := [2][]int{{1}, {2}}
+	arrClone := DeepClone(arr)
+	arrClone[0][0] = 9
+	if arr[0][0] != 1 {
+		t.Fatal("array elements share slices")
+	}
+
+	shared := []int{1, 2, 3}
+	pair := struct{ A, B []int }{shared, shared}
+	pairClone := DeepClone(pair)
+	pairClone.A[0] = 9
+	if pairClone.B[0] != 9 || shared[0] != 1 {
+		t.Fatal("identical slices should stay aliased in the clone only")
+	}
+
+	var shape Shape = NewSquare()
+	shape.SetId("sq")
+	cloned := DeepClone(shape)
+	cloned.SetId("other")
+	if shape.GetId() != "sq" {
+		t.Fatal("interface clone shares the concrete value")
+	}
+}
@@ -0,0 +1,265 @@
+package designpattern
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+)
+
+// 原型缓存持久化
+// ShapeCache 以带版本号的快照保存为 JSON 或 gob，每个原型记录具体类型，
+// 加载时按类型重新创建形状。旧版本的快照通过注册的迁移函数逐级升级到当前版本。
+// 除 id 以外还有其它字段的形状需要实现 StatefulShape，字段以 JSON 保存在记录的 State 中，
+// 两种格式使用同一种表示，迁移函数不需要区分格式。
+
+// ShapeCacheSchemaVersion 当前快照版本
+const ShapeCacheSchemaVersion = 1
+
+// ShapeFormat 快照的编码格式
+type ShapeFormat int
+
+const (
+	ShapeFormatJSON ShapeFormat = iota
+	ShapeFormatGob
+)
+
+func (f ShapeFormat) String() string {
+	switch f {
+	case ShapeFormatJSON:
+		return "json"
+	case ShapeFormatGob:
+		return "gob"
+	}
+	return fmt.Sprintf("ShapeFormat(%d)", int(f))
+}
+
+var (
+	ErrUnknownShapeType   = errors.New("unknown shape type")
+	ErrShapeTypeExists    = errors.New("shape type already registered")
+	ErrShapeSchemaVersion = errors.New("unsupported shape cache schema version")
+	ErrShapeMigration     = errors.New("missing shape cache migration")
+	ErrUnknownShapeFormat = errors.New("unknown shape cache format")
+	ErrShapeState         = errors.New("shape state cannot be persisted")
+)
+
+// StatefulShape 由带有额外字段的形状实现，MarshalState 返回的数据必须是 JSON
+type StatefulShape interface {
+	Shape
+	MarshalState() (json.RawMessage, error)
+	UnmarshalState(state json.RawMessage) error
+}
+
+// ShapeRecord 快照中的一个原型
+type ShapeRecord struct {
+	Type  string          `json:"type"`
+	Id    string          `json:"id"`
+	State json.RawMessage `json:"state,omitempty"`
+}
+
+// ShapeCacheSnapshot 缓存快照，记录按 id 排序
+type ShapeCacheSnapshot struct {
+	Version int           `json:"version"`
+	Shapes  []ShapeRecord `json:"shapes"`
+}
+
+// ShapeMigration 把快照从某个版本升级到下一个版本，Version 由调用方更新
+type ShapeMigration func(snapshot *ShapeCacheSnapshot) error
+
+// ShapeCacheCodec 负责缓存与快照之间的转换，可并发使用
+type ShapeCacheCodec struct {
+	mu         sync.RWMutex
+	types      map[string]func() Shape
+	migrations map[int]ShapeMigration
+}
+
+// NewShapeCacheCodec 返回已注册 Circle、Square、Rectangle 和 Polyline 的编解码器
+func NewShapeCacheCodec() *ShapeCacheCodec {
+	c := &ShapeCacheCodec{
+		types:      make(map[string]func() Shape),
+		migrations: make(map[int]ShapeMigration),
+	}
+	c.types["Circle"] = func() Shape { return NewCircle() }
+	c.types["Square"] = func() Shape { return NewSquare() }
+	c.types["Rectangle"] = func() Shape { return NewRectangle() }
+	c.types["Polyline"] = func() Shape { return NewPolyline(nil, nil) }
+	return c
+}
+
+// RegisterType 注册自定义形状类型，typ 需要与 GetType() 的返回值一致
+func (c *ShapeCacheCodec) RegisterType(typ string, ctor func() Shape) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.types[typ]; ok {
+		return fmt.Errorf("%w: %s", ErrShapeTypeExists, typ)
+	}
+	c.types[typ] = ctor
+	return nil
+}
+
+// RegisterMigration 注册从 from 升级到 from+1 的迁移函数，重复注册会覆盖
+func (c *ShapeCacheCodec) RegisterMigration(from int, migration ShapeMigration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.migrations[from] = migration
+}
+
+// Snapshot 生成缓存的快照。形状有 BaseShape 以外的字段却没有实现 StatefulShape 时
+// 返回 ErrShapeState，而不是悄悄丢掉这些字段。
+func (c *ShapeCacheCodec) Snapshot(cache *ShapeCache) (ShapeCacheSnapshot, error) {
+	snapshot := ShapeCacheSnapshot{Version: ShapeCacheSchemaVersion}
+	for _, id := range cache.Ids() {
+		shape, err := cache.GetShape(id)
+		if err != nil {
+			continue // 并发删除
+		}
+		record := ShapeRecord{Type: shape.GetType(), Id: id}
+		if stateful, ok := shape.(StatefulShape); ok {
+			if record.State, err = stateful.MarshalState(); err != nil {
+				return ShapeCacheSnapshot{}, fmt.Errorf("%w: %s: %w", ErrShapeState, id, err)
+			}
+		} else if hasShapeState(shape) {
+			return ShapeCacheSnapshot{}, fmt.Errorf("%w: %s (%T) does not implement StatefulShape", ErrShapeState, id, shape)
+		}
+		snapshot.Shapes = append(snapshot.Shapes, record)
+	}
+	return snapshot, nil
+}
+
+// hasShapeState 检查形状的结构体中是否有 BaseShape 以外的字段
+func hasShapeState(shape Shape) bool {
+	v := reflect.ValueOf(shape)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return true
+	}
+	baseShape := reflect.TypeOf(BaseShape{})
+	for i := 0; i < v.NumField(); i++ {
+		if field := v.Type().Field(i); field.Type != baseShape && field.Type.Size() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Restore 把快照升级到当前版本后重新创建缓存
+func (c *ShapeCacheCodec) Restore(snapshot ShapeCacheSnapshot) (*ShapeCache, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if snapshot.Version > ShapeCacheSchemaVersion || snapshot.Version < 0 {
+		return nil, fmt.Errorf("%w: %d", ErrShapeSchemaVersion, snapshot.Version)
+	}
+	for snapshot.Version < ShapeCacheSchemaVersion {
+		migrate, ok := c.migrations[snapshot.Version]
+		if !ok {
+			return nil, fmt.Errorf("%w: from version %d", ErrShapeMigration, snapshot.Version)
+		}
+		if err := migrate(&snapshot); err != nil {
+			return nil, fmt.Errorf("migrate shape cache from version %d: %w", snapshot.Version, err)
+		}
+		snapshot.Version++
+	}
+
+	cache := NewShapeCache()
+	for _, record := range snapshot.Shapes {
+		ctor, ok := c.types[record.Type]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownShapeType, record.Type)
+		}
+		shape := ctor()
+		shape.SetId(record.Id)
+		if len(record.State) > 0 {
+			stateful, ok := shape.(StatefulShape)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s (%T) does not implement StatefulShape", ErrShapeState, record.Id, shape)
+			}
+			if err := stateful.UnmarshalState(record.State); err != nil {
+				return nil, fmt.Errorf("%w: %s: %w", ErrShapeState, record.Id, err)
+			}
+		}
+		if err := cache.Register(shape); err != nil {
+			return nil, err
+		}
+	}
+	return cache, nil
+}
+
+// Encode 以指定格式写出缓存
+func (c *ShapeCacheCodec) Encode(w io.Writer, cache *ShapeCache, format ShapeFormat) error {
+	snapshot, err := c.Snapshot(cache)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case ShapeFormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(snapshot)
+	case ShapeFormatGob:
+		return gob.NewEncoder(w).Encode(snapshot)
+	}
+	return fmt.Errorf("%w: %v", ErrUnknownShapeFormat, format)
+}
+
+// Decode 以指定格式读取缓存
+func (c *ShapeCacheCodec) Decode(r io.Reader, format ShapeFormat) (*ShapeCache, error) {
+	var snapshot ShapeCacheSnapshot
+	var err error
+	switch format {
+	case ShapeFormatJSON:
+		err = json.NewDecoder(r).Decode(&snapshot)
+	case ShapeFormatGob:
+		err = gob.NewDecoder(r).Decode(&snapshot)
+	default:
+		return nil, fmt.Errorf("%w: %v", ErrUnknownShapeFormat, format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode shape cache (%v): %w", format, err)
+	}
+	return c.Restore(snapshot)
+}
+
+// DefaultShapeCacheCodec SaveShapeCache 和 LoadShapeCache 使用的编解码器
+var DefaultShapeCacheCodec = NewShapeCacheCodec()
+
+// shapeFormatFor 根据扩展名选择格式，.gob 为 gob，其它为 JSON
+func shapeFormatFor(path string) ShapeFormat {
+	if filepath.Ext(path) == ".gob" {
+		return ShapeFormatGob
+	}
+	return ShapeFormatJSON
+}
+
+// SaveShapeCache 把缓存保存到文件，先写临时文件再重命名，避免留下写了一半的文件
+func SaveShapeCache(path string, cache *ShapeCache) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := DefaultShapeCacheCodec.Encode(tmp, cache, shapeFormatFor(path)); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadShapeCache 从文件加载缓存
+func LoadShapeCache(path string) (*ShapeCache, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return DefaultShapeCacheCodec.Decode(f, shapeFormatFor(path))
+}
@@ -0,0 +1,261 @@
+package designpattern
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// polygonState 是 polygon 可以持久化的字段，labels 以点的下标保存
+type polygonState struct {
+	Points []point         `json:"points"`
+	Labels map[string]int  `json:"labels,omitempty"`
+	Origin *point          `json:"origin,omitempty"`
+	Style  json.RawMessage `json:"style,omitempty"`
+}
+
+func (p *polygon) MarshalState() (json.RawMessage, error) {
+	state := polygonState{Points: p.points, Origin: p.origin}
+	for name, label := range p.labels {
+		i := slices.Index(p.points, *label)
+		if i < 0 {
+			return nil, fmt.Errorf("label %q is not a point of the polygon", name)
+		}
+		if state.Labels == nil {
+			state.Labels = make(map[string]int)
+		}
+		state.Labels[name] = i
+	}
+	if p.style != nil {
+		style, err := json.Marshal(p.style)
+		if err != nil {
+			return nil, err
+		}
+		state.Style = style
+	}
+	return json.Marshal(state)
+}
+
+func (p *polygon) UnmarshalState(data json.RawMessage) error {
+	var state polygonState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	p.points, p.origin, p.labels, p.style = state.Points, state.Origin, nil, nil
+	for name, i := range state.Labels {
+		if i < 0 || i >= len(p.points) {
+			return fmt.Errorf("label %q refers to point %d of %d", name, i, len(p.points))
+		}
+		if p.labels == nil {
+			p.labels = make(map[string]*point)
+		}
+		p.labels[name] = &p.points[i]
+	}
+	if state.Style != nil {
+		return json.Unmarshal(state.Style, &p.style)
+	}
+	return nil
+}
+
+func loadedShapeCache(t *testing.T) *ShapeCache {
+	t.Helper()
+	cache := NewShapeCache()
+	cache.LoadCache()
+	extra := NewCircle()
+	extra.SetId("big-circle")
+	if err := cache.Register(extra); err != nil {
+		t.Fatal(err)
+	}
+	return cache
+}
+
+func assertSameShapes(t *testing.T, got, want *ShapeCache) {
+	t.Helper()
+	if !reflect.DeepEqual(got.Ids(), want.Ids()) {
+		t.Fatalf("Ids() = %v, want %v", got.Ids(), want.Ids())
+	}
+	for _, id := range want.Ids() {
+		g, err := got.GetShape(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, _ := want.GetShape(id)
+		if reflect.TypeOf(g) != reflect.TypeOf(w) || g.GetType() != w.GetType() || g.GetId() != id {
+			t.Fatalf("%s = %T %s/%s, want %T %s", id, g, g.GetType(), g.GetId(), w, w.GetType())
+		}
+		if stateful, ok := w.(StatefulShape); ok {
+			want, err := stateful.MarshalState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			state, err := g.(StatefulShape).MarshalState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(state) != string(want) {
+				t.Fatalf("%s state = %s, want %s", id, state, want)
+			}
+		}
+	}
+}
+
+func TestShapeCacheRoundTrip(t *testing.T) {
+	cache := loadedShapeCache(t)
+	codec := NewShapeCacheCodec()
+	for _, format := range []ShapeFormat{ShapeFormatJSON, ShapeFormatGob} {
+		t.Run(format.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := codec.Encode(&buf, cache, format); err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := codec.Decode(&buf, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertSameShapes(t, loaded, cache)
+		})
+	}
+}
+
+func TestShapeCacheFiles(t *testing.T) {
+	cache := loadedShapeCache(t)
+	dir := t.TempDir()
+	for _, name := range []string{"shapes.json", "shapes.gob"} {
+		path := filepath.Join(dir, name)
+		if err := SaveShapeCache(path, cache); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadShapeCache(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertSameShapes(t, loaded, cache)
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.tmp*"))
+	if len(matches) != 0 {
+		t.Fatalf("temporary files left behind: %v", matches)
+	}
+}
+
+func TestShapeCacheCustomType(t *testing.T) {
+	codec := NewShapeCacheCodec()
+	cache := NewShapeCache()
+	points := []point{{0, 0}, {4, 0}, {0, 3}}
+	shape := &polygon{
+		BaseShape: BaseShape{id: "tri", typ: "Polygon"},
+		points:    points,
+		labels:    map[string]*point{"apex": &points[2]},
+		origin:    &point{1, 1},
+		style:     map[string]interface{}{"width": 2.0},
+	}
+	if err := cache.Register(shape); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := codec.Encode(&buf, cache, ShapeFormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.String()
+	if _, err := codec.Decode(strings.NewReader(data), ShapeFormatJSON); !errors.Is(err, ErrUnknownShapeType) {
+		t.Fatalf("err = %v, want ErrUnknownShapeType", err)
+	}
+
+	ctor := func() Shape { return &polygon{BaseShape: BaseShape{typ: "Polygon"}} }
+	if err := codec.RegisterType("Polygon", ctor); err != nil {
+		t.Fatal(err)
+	}
+	if err := codec.RegisterType("Polygon", ctor); !errors.Is(err, ErrShapeTypeExists) {
+		t.Fatalf("err = %v, want ErrShapeTypeExists", err)
+	}
+	for _, format := range []ShapeFormat{ShapeFormatJSON, ShapeFormatGob} {
+		buf.Reset()
+		if err := codec.Encode(&buf, cache, format); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := codec.Decode(&buf, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertSameShapes(t, loaded, cache)
+
+		got, _ := loaded.GetShape("tri")
+		tri := got.(*polygon)
+		if !reflect.DeepEqual(tri.points, points) || *tri.origin != (point{1, 1}) || *tri.labels["apex"] != points[2] {
+			t.Fatalf("%v: loaded polygon = %+v", format, tri)
+		}
+	}
+}
+
+// taggedShape 有额外的字段，但没有实现 StatefulShape
+type taggedShape struct {
+	BaseShape
+	tag string
+}
+
+func (s *taggedShape) Clone() Shape {
+	return DeepClone(s)
+}
+
+func TestShapeCacheUnpersistableState(t *testing.T) {
+	cache := NewShapeCache()
+	if err := cache.Register(&taggedShape{BaseShape: BaseShape{id: "t1", typ: "Tagged"}, tag: "keep me"}); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := NewShapeCacheCodec().Encode(&buf, cache, ShapeFormatJSON); !errors.Is(err, ErrShapeState) {
+		t.Fatalf("Encode err = %v, want ErrShapeState", err)
+	}
+
+	// 快照中有状态，但注册的类型无法恢复
+	codec := NewShapeCacheCodec()
+	snapshot := `{"version": 1, "shapes": [{"type": "Circle", "id": "c1", "state": {"radius": 2}}]}`
+	if _, err := codec.Decode(strings.NewReader(snapshot), ShapeFormatJSON); !errors.Is(err, ErrShapeState) {
+		t.Fatalf("Decode err = %v, want ErrShapeState", err)
+	}
+}
+
+func TestShapeCacheMigration(t *testing.T) {
+	// 版本 0 是没有 version 字段的旧文件，类型名为小写
+	legacy := `{"shapes": [{"type": "circle", "id": "c1"}, {"type": "square", "id": "s1"}]}`
+
+	codec := NewShapeCacheCodec()
+	if _, err := codec.Decode(strings.NewReader(legacy), ShapeFormatJSON); !errors.Is(err, ErrShapeMigration) {
+		t.Fatalf("err = %v, want ErrShapeMigration", err)
+	}
+
+	codec.RegisterMigration(0, func(snapshot *ShapeCacheSnapshot) error {
+		for i, record := range snapshot.Shapes {
+			snapshot.Shapes[i].Type = strings.ToUpper(record.Type[:1]) + record.Type[1:]
+		}
+		return nil
+	})
+	cache, err := codec.Decode(strings.NewReader(legacy), ShapeFormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shape, err := cache.GetShape("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := shape.(*Square); !ok {
+		t.Fatalf("s1 = %T, want *Square", shape)
+	}
+
+	future := `{"version": 99, "shapes": []}`
+	if _, err := codec.Decode(strings.NewReader(future), ShapeFormatJSON); !errors.Is(err, ErrShapeSchemaVersion) {
+		t.Fatalf("err = %v, want ErrShapeSchemaVersion", err)
+	}
+
+	failing := errors.New("boom")
+	codec.RegisterMigration(0, func(*ShapeCacheSnapshot) error { return failing })
+	if _, err := codec.Decode(strings.NewReader(legacy), ShapeFormatJSON); !errors.Is(err, failing) {
+		t.Fatalf("err = %v, want migration error", err)
+	}
+}
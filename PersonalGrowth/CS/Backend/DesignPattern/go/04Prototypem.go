@@ -1,11 +1,24 @@
 package designpattern
 
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
 const (
 	CircleId    = "circle"
 	SquareId    = "square"
 	RectangleId = "rectangle"
 )
 
+var (
+	ErrShapeNotFound = errors.New("shape not found")
+	ErrShapeExists   = errors.New("shape already registered")
+	ErrShapeNoId     = errors.New("shape has no id")
+)
+
 // Shape 是形状接口
 type Shape interface {
 	GetType() string
@@ -44,12 +57,7 @@ func NewCircle() *Circle {
 }
 
 func (c *Circle) Clone() Shape {
-	return &Circle{
-		BaseShape: BaseShape{
-			id:  c.id,
-			typ: c.typ,
-		},
-	}
+	return DeepClone(c)
 }
 
 // Rectangle 实现
@@ -64,12 +72,7 @@ func NewRectangle() *Rectangle {
 }
 
 func (r *Rectangle) Clone() Shape {
-	return &Rectangle{
-		BaseShape: BaseShape{
-			id:  r.id,
-			typ: r.typ,
-		},
-	}
+	return DeepClone(r)
 }
 
 // Square 实现
@@ -84,16 +87,13 @@ func NewSquare() *Square {
 }
 
 func (s *Square) Clone() Shape {
-	return &Square{
-		BaseShape: BaseShape{
-			id:  s.id,
-			typ: s.typ,
-		},
-	}
+	return DeepClone(s)
 }
 
-// ShapeCache 形状缓存管理器
+// ShapeCache 形状缓存管理器，可并发使用
+// 缓存保存原型的克隆，注册后再修改原对象或取出的形状都不会影响缓存
 type ShapeCache struct {
+	mu       sync.RWMutex
 	shapeMap map[string]Shape
 }
 
@@ -103,21 +103,70 @@ func NewShapeCache() *ShapeCache {
 	}
 }
 
-func (c *ShapeCache) GetShape(id string) Shape {
-	cachedShape := c.shapeMap[id]
-	return cachedShape.Clone()
+// GetShape 返回 id 对应原型的克隆
+func (c *ShapeCache) GetShape(id string) (Shape, error) {
+	c.mu.RLock()
+	cachedShape, ok := c.shapeMap[id]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrShapeNotFound, id)
+	}
+	return cachedShape.Clone(), nil
 }
 
+// Register 以 shape.GetId() 注册原型，id 已存在时返回 ErrShapeExists
+func (c *ShapeCache) Register(shape Shape) error {
+	if shape == nil {
+		return errors.New("shape is nil")
+	}
+	id := shape.GetId()
+	if id == "" {
+		return ErrShapeNoId
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.shapeMap[id]; ok {
+		return fmt.Errorf("%w: %q", ErrShapeExists, id)
+	}
+	c.shapeMap[id] = shape.Clone()
+	return nil
+}
+
+// Remove 删除原型，返回之前是否存在
+func (c *ShapeCache) Remove(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.shapeMap[id]
+	delete(c.shapeMap, id)
+	return ok
+}
+
+// Ids 返回已注册原型的 id，按字母排序
+func (c *ShapeCache) Ids() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ids := make([]string, 0, len(c.shapeMap))
+	for id := range c.shapeMap {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// LoadCache 注册内置的原型，已存在的同名原型会被覆盖
 func (c *ShapeCache) LoadCache() {
 	circle := NewCircle()
 	circle.SetId(CircleId)
-	c.shapeMap[circle.GetId()] = circle
 
 	square := NewSquare()
 	square.SetId(SquareId)
-	c.shapeMap[square.GetId()] = square
 
 	rectangle := NewRectangle()
 	rectangle.SetId(RectangleId)
-	c.shapeMap[rectangle.GetId()] = rectangle
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, shape := range []Shape{circle, square, rectangle} {
+		c.shapeMap[shape.GetId()] = shape
+	}
 }
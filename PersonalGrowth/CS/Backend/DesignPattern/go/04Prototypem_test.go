@@ -1,7 +1,10 @@
 package designpattern
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
+	"sync"
 	"testing"
 )
 
@@ -9,15 +12,101 @@ func TestPrototype(t *testing.T) {
 	cache := NewShapeCache()
 	cache.LoadCache()
 
-	shape1 := cache.GetShape(CircleId)
-	shape2 := cache.GetShape(SquareId)
-	shape3 := cache.GetShape(RectangleId)
+	for _, tt := range []struct {
+		typ, id string
+	}{
+		{"Circle", CircleId},
+		{"Square", SquareId},
+		{"Rectangle", RectangleId},
+	} {
+		shape, err := cache.GetShape(tt.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		clone := shape.Clone()
+		if clone.GetType() != tt.typ || clone.GetId() != tt.id {
+			t.Errorf("clone = %s/%s, want %s/%s", clone.GetType(), clone.GetId(), tt.typ, tt.id)
+		}
+	}
 
-	cshape1 := shape1.Clone()
-	cshape2 := shape2.Clone()
-	cshape3 := shape3.Clone()
+	// 修改克隆不影响缓存中的原型
+	shape, _ := cache.GetShape(CircleId)
+	shape.SetId("changed")
+	if shape, _ := cache.GetShape(CircleId); shape.GetId() != CircleId {
+		t.Fatalf("cached prototype id = %q, want %q", shape.GetId(), CircleId)
+	}
+}
+
+func TestShapeCacheRegistry(t *testing.T) {
+	cache := NewShapeCache()
+	if _, err := cache.GetShape(CircleId); !errors.Is(err, ErrShapeNotFound) {
+		t.Fatalf("err = %v, want ErrShapeNotFound", err)
+	}
+
+	big := NewCircle()
+	big.SetId("big-circle")
+	if err := cache.Register(big); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Register(big); !errors.Is(err, ErrShapeExists) {
+		t.Fatalf("duplicate err = %v, want ErrShapeExists", err)
+	}
+	if err := cache.Register(NewSquare()); !errors.Is(err, ErrShapeNoId) {
+		t.Fatalf("err = %v, want ErrShapeNoId", err)
+	}
+	if err := cache.Register(nil); err == nil {
+		t.Fatal("Register(nil) succeeded")
+	}
+
+	// 注册后修改原对象不影响缓存
+	big.SetId("renamed")
+	if _, err := cache.GetShape("big-circle"); err != nil {
+		t.Fatal(err)
+	}
+
+	cache.LoadCache()
+	want := []string{"big-circle", CircleId, RectangleId, SquareId}
+	if got := cache.Ids(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Ids() = %v, want %v", got, want)
+	}
+
+	if !cache.Remove("big-circle") {
+		t.Fatal("Remove(big-circle) = false")
+	}
+	if cache.Remove("big-circle") {
+		t.Fatal("second Remove(big-circle) = true")
+	}
+	if _, err := cache.GetShape("big-circle"); !errors.Is(err, ErrShapeNotFound) {
+		t.Fatalf("err = %v, want ErrShapeNotFound", err)
+	}
+}
+
+func TestShapeCacheConcurrent(t *testing.T) {
+	cache := NewShapeCache()
+	cache.LoadCache()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			square := NewSquare()
+			square.SetId(fmt.Sprintf("square-%d", i))
+			if err := cache.Register(square); err != nil {
+				t.Error(err)
+				return
+			}
+			if _, err := cache.GetShape(CircleId); err != nil {
+				t.Error(err)
+			}
+			cache.Ids()
+			if i%2 == 0 {
+				cache.Remove(square.GetId())
+			}
+		}(i)
+	}
+	wg.Wait()
 
-	fmt.Println(cshape1.GetType())
-	fmt.Println(cshape2.GetType())
-	fmt.Println(cshape3.GetType())
+	if got := len(cache.Ids()); got != 3+25 {
+		t.Fatalf("len(Ids()) = %d, want 28", got)
+	}
 }
@@ -1,9 +1,6 @@
 package designpattern
 
-import (
-	"fmt"
-	"sync"
-)
+import "sync"
 
 func NewSingleton() *Singleton {
 	return &Singleton{}
@@ -11,18 +8,17 @@ func NewSingleton() *Singleton {
 
 type Singleton struct {
 	Name string
+
+	flagsOnce sync.Once
+	flags     *FeatureFlags
 }
 
-var (
-	once      sync.Once
-	singleton *Singleton
-)
+var singleton = NewLazy(func() (*Singleton, error) {
+	return &Singleton{}, nil
+})
 
 func GetSingleton() *Singleton {
-	once.Do(func() {
-		singleton = &Singleton{}
-	})
-	return singleton
+	return singleton.MustGet()
 }
 
 func (s *Singleton) GetName() string {
@@ -34,5 +30,5 @@ func (s *Singleton) SetName(name string) {
 }
 
 func (s *Singleton) PrintName() {
-	fmt.Println(s.Name)
+	emit("Singleton", s.Name)
 }
@@ -0,0 +1,400 @@
+package designpattern
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 功能开关
+// 开关定义保存在 JSON 文件中，每个开关有若干取值（variant），按顺序匹配目标规则，
+// 规则可以直接返回某个取值，也可以按用户 key 的哈希值分流。重新加载时先完整校验，
+// 校验通过后才替换正在使用的定义。
+//
+// 文件格式：
+//
+//	{"flags": [{
+//	  "key": "new-checkout",
+//	  "enabled": true,
+//	  "variants": {"on": true, "off": false},
+//	  "default": "off",
+//	  "rules": [
+//	    {"name": "staff", "conditions": [{"attribute": "email", "op": "suffix", "values": ["@example.com"]}], "variant": "on"},
+//	    {"name": "beta", "rollout": [{"variant": "on", "weight": 20}, {"variant": "off", "weight": 80}]}
+//	  ]
+//	}]}
+
+var (
+	ErrFlagNotFound = errors.New("feature flag not found")
+	ErrInvalidFlag  = errors.New("invalid feature flag")
+)
+
+// FlagOperator 条件的比较方式
+type FlagOperator string
+
+const (
+	FlagOpEquals    FlagOperator = "eq"
+	FlagOpNotEquals FlagOperator = "neq"
+	FlagOpIn        FlagOperator = "in"
+	FlagOpNotIn     FlagOperator = "not_in"
+	FlagOpPrefix    FlagOperator = "prefix"
+	FlagOpSuffix    FlagOperator = "suffix"
+	FlagOpContains  FlagOperator = "contains"
+)
+
+// FlagCondition 对用户属性的条件，Attribute 为 "key" 时比较 FlagContext.Key
+// 除 neq 和 not_in 外，属性不存在时条件不成立
+type FlagCondition struct {
+	Attribute string       `json:"attribute"`
+	Operator  FlagOperator `json:"op"`
+	Values    []string     `json:"values"`
+}
+
+// WeightedVariant 分流中的一个取值，同一规则中 Weight 之和为 100
+type WeightedVariant struct {
+	Variant string `json:"variant"`
+	Weight  int    `json:"weight"`
+}
+
+// FlagRule 目标规则，所有条件都成立时匹配，返回 Variant 或按 Rollout 分流
+type FlagRule struct {
+	Name       string            `json:"name"`
+	Conditions []FlagCondition   `json:"conditions,omitempty"`
+	Variant    string            `json:"variant,omitempty"`
+	Rollout    []WeightedVariant `json:"rollout,omitempty"`
+}
+
+// FeatureFlag 开关定义，关闭或没有规则匹配时返回 Default
+type FeatureFlag struct {
+	Key      string                 `json:"key"`
+	Enabled  bool                   `json:"enabled"`
+	Variants map[string]interface{} `json:"variants"`
+	Default  string                 `json:"default"`
+	Rules    []FlagRule             `json:"rules,omitempty"`
+}
+
+// FlagContext 被评估的用户，Key 用于分流
+type FlagContext struct {
+	Key        string
+	Attributes map[string]string
+}
+
+func (c FlagContext) attribute(name string) (string, bool) {
+	if name == "key" {
+		return c.Key, c.Key != ""
+	}
+	value, ok := c.Attributes[name]
+	return value, ok
+}
+
+// FlagReason 评估结果的原因
+type FlagReason string
+
+const (
+	FlagReasonDisabled  FlagReason = "disabled"
+	FlagReasonRuleMatch FlagReason = "rule_match"
+	FlagReasonRollout   FlagReason = "rollout"
+	FlagReasonDefault   FlagReason = "default"
+)
+
+// FlagEvaluation 评估结果，Rule 为匹配的规则名称，RuleIndex 为其下标，没有匹配时为 -1
+type FlagEvaluation struct {
+	Flag      string
+	Variant   string
+	Value     interface{}
+	Reason    FlagReason
+	Rule      string
+	RuleIndex int
+}
+
+type flagFile struct {
+	Flags []*FeatureFlag `json:"flags"`
+}
+
+func (f *FeatureFlag) validate() error {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s: %s", ErrInvalidFlag, f.Key, fmt.Sprintf(format, args...))
+	}
+	if f.Key == "" {
+		return fmt.Errorf("%w: missing key", ErrInvalidFlag)
+	}
+	if _, ok := f.Variants[f.Default]; !ok {
+		return invalid("default variant %q is not defined", f.Default)
+	}
+	for i, rule := range f.Rules {
+		if rule.Name == "" {
+			return invalid("rule %d has no name", i)
+		}
+		for _, cond := range rule.Conditions {
+			switch cond.Operator {
+			case FlagOpEquals, FlagOpNotEquals, FlagOpIn, FlagOpNotIn, FlagOpPrefix, FlagOpSuffix, FlagOpContains:
+			default:
+				return invalid("rule %s: unknown operator %q", rule.Name, cond.Operator)
+			}
+		}
+		if (rule.Variant == "") == (len(rule.Rollout) == 0) {
+			return invalid("rule %s must have exactly one of variant and rollout", rule.Name)
+		}
+		if rule.Variant != "" {
+			if _, ok := f.Variants[rule.Variant]; !ok {
+				return invalid("rule %s: variant %q is not defined", rule.Name, rule.Variant)
+			}
+			continue
+		}
+		total := 0
+		for _, wv := range rule.Rollout {
+			if _, ok := f.Variants[wv.Variant]; !ok {
+				return invalid("rule %s: variant %q is not defined", rule.Name, wv.Variant)
+			}
+			if wv.Weight < 0 {
+				return invalid("rule %s: negative weight", rule.Name)
+			}
+			total += wv.Weight
+		}
+		if total != 100 {
+			return invalid("rule %s: rollout weights sum to %d, want 100", rule.Name, total)
+		}
+	}
+	return nil
+}
+
+func (c FlagCondition) matches(ctx FlagContext) bool {
+	value, ok := ctx.attribute(c.Attribute)
+	switch c.Operator {
+	case FlagOpNotEquals, FlagOpNotIn:
+		return !ok || !containsString(c.Values, value)
+	}
+	if !ok {
+		return false
+	}
+	for _, want := range c.Values {
+		switch c.Operator {
+		case FlagOpEquals, FlagOpIn:
+			if value == want {
+				return true
+			}
+		case FlagOpPrefix:
+			if strings.HasPrefix(value, want) {
+				return true
+			}
+		case FlagOpSuffix:
+			if strings.HasSuffix(value, want) {
+				return true
+			}
+		case FlagOpContains:
+			if strings.Contains(value, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// rolloutBucket 把 flag、规则和用户 key 哈希到 [0, 100)，同一用户的结果稳定
+func rolloutBucket(flag, rule, key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(flag + "/" + rule + "/" + key))
+	return int(h.Sum32() % 100)
+}
+
+func (f *FeatureFlag) evaluate(ctx FlagContext) FlagEvaluation {
+	result := func(variant string, reason FlagReason, rule string, index int) FlagEvaluation {
+		return FlagEvaluation{
+			Flag:      f.Key,
+			Variant:   variant,
+			Value:     f.Variants[variant],
+			Reason:    reason,
+			Rule:      rule,
+			RuleIndex: index,
+		}
+	}
+	if !f.Enabled {
+		return result(f.Default, FlagReasonDisabled, "", -1)
+	}
+rules:
+	for i, rule := range f.Rules {
+		for _, cond := range rule.Conditions {
+			if !cond.matches(ctx) {
+				continue rules
+			}
+		}
+		if rule.Variant != "" {
+			return result(rule.Variant, FlagReasonRuleMatch, rule.Name, i)
+		}
+		bucket := rolloutBucket(f.Key, rule.Name, ctx.Key)
+		for _, wv := range rule.Rollout {
+			if bucket < wv.Weight {
+				return result(wv.Variant, FlagReasonRollout, rule.Name, i)
+			}
+			bucket -= wv.Weight
+		}
+	}
+	return result(f.Default, FlagReasonDefault, "", -1)
+}
+
+// FeatureFlags 开关集合，可并发使用
+type FeatureFlags struct {
+	mu      sync.RWMutex
+	flags   map[string]*FeatureFlag
+	path    string
+	modTime time.Time // 上一次加载的文件的修改时间
+}
+
+func NewFeatureFlags() *FeatureFlags {
+	return &FeatureFlags{flags: make(map[string]*FeatureFlag)}
+}
+
+// Load 读取并校验开关定义，成功后整体替换现有定义
+func (f *FeatureFlags) Load(r io.Reader) error {
+	var file flagFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return fmt.Errorf("load feature flags: %w", err)
+	}
+	flags := make(map[string]*FeatureFlag, len(file.Flags))
+	for i, flag := range file.Flags {
+		if flag == nil {
+			return fmt.Errorf("%w: flag %d is null", ErrInvalidFlag, i)
+		}
+		if err := flag.validate(); err != nil {
+			return err
+		}
+		if _, ok := flags[flag.Key]; ok {
+			return fmt.Errorf("%w: duplicate key %s", ErrInvalidFlag, flag.Key)
+		}
+		flags[flag.Key] = flag
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.flags = flags
+	return nil
+}
+
+// LoadFile 从文件加载，之后可以通过 Reload 重新加载同一个文件
+func (f *FeatureFlags) LoadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if err := f.Load(file); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	f.mu.Lock()
+	f.path = path
+	f.modTime = info.ModTime()
+	f.mu.Unlock()
+	return nil
+}
+
+// Reload 重新加载上一次 LoadFile 的文件，失败时保留原有定义
+func (f *FeatureFlags) Reload() error {
+	f.mu.RLock()
+	path := f.path
+	f.mu.RUnlock()
+	if path == "" {
+		return errors.New("feature flags were not loaded from a file")
+	}
+	return f.LoadFile(path)
+}
+
+// Watch 每隔 interval 检查文件的修改时间，与上一次成功加载时不同就重新加载，直到 ctx 结束。
+// 每次检查都读取当前的文件路径，因此可以在 LoadFile 之前启动。
+// 重新加载失败时原有定义保持不变，之后每次检查都会重试，直到加载成功，
+// 这样文件在写入过程中被读到时，即使写完后修改时间没有变化也能加载到完整的内容。
+// 失败时调用 onError（可以为 nil），同一个修改时间只报告一次。
+func (f *FeatureFlags) Watch(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var reported time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		f.mu.RLock()
+		path, loaded := f.path, f.modTime
+		f.mu.RUnlock()
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err == nil {
+			if info.ModTime().Equal(loaded) {
+				continue
+			}
+			if err = f.Reload(); err != nil && info.ModTime().Equal(reported) {
+				continue
+			}
+			reported = info.ModTime()
+		}
+		if err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+// Keys 返回所有开关名称，按字母排序
+func (f *FeatureFlags) Keys() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	keys := make([]string, 0, len(f.flags))
+	for key := range f.flags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Evaluate 为 ctx 评估开关
+func (f *FeatureFlags) Evaluate(key string, ctx FlagContext) (FlagEvaluation, error) {
+	f.mu.RLock()
+	flag, ok := f.flags[key]
+	f.mu.RUnlock()
+	if !ok {
+		return FlagEvaluation{Flag: key, RuleIndex: -1}, fmt.Errorf("%w: %s", ErrFlagNotFound, key)
+	}
+	return flag.evaluate(ctx), nil
+}
+
+// Bool 返回布尔开关的值，开关不存在或取值不是布尔值时返回 fallback
+func (f *FeatureFlags) Bool(key string, ctx FlagContext, fallback bool) bool {
+	evaluation, err := f.Evaluate(key, ctx)
+	if err != nil {
+		return fallback
+	}
+	value, ok := evaluation.Value.(bool)
+	if !ok {
+		return fallback
+	}
+	return value
+}
+
+// Flags 返回配置中的功能开关，第一次调用时创建空的开关集合
+func (s *Singleton) Flags() *FeatureFlags {
+	s.flagsOnce.Do(func() {
+		s.flags = NewFeatureFlags()
+	})
+	return s.flags
+}
@@ -0,0 +1,262 @@
+package designpattern
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testFlags = `{"flags": [
+	{
+		"key": "new-checkout",
+		"enabled": true,
+		"variants": {"on": true, "off": false},
+		"default": "off",
+		"rules": [
+			{"name": "blocked", "conditions": [{"attribute": "country", "op": "in", "values": ["XX", "YY"]}], "variant": "off"},
+			{"name": "staff", "conditions": [{"attribute": "email", "op": "suffix", "values": ["@example.com"]}], "variant": "on"},
+			{"name": "beta", "conditions": [{"attribute": "plan", "op": "eq", "values": ["pro"]}],
+			 "rollout": [{"variant": "on", "weight": 30}, {"variant": "off", "weight": 70}]}
+		]
+	},
+	{
+		"key": "button-color",
+		"enabled": true,
+		"variants": {"blue": "#0000ff", "green": "#00ff00"},
+		"default": "blue",
+		"rules": [{"name": "everyone", "rollout": [{"variant": "blue", "weight": 50}, {"variant": "green", "weight": 50}]}]
+	},
+	{
+		"key": "kill-switch",
+		"enabled": false,
+		"variants": {"on": true, "off": false},
+		"default": "off",
+		"rules": [{"name": "all", "variant": "on"}]
+	}
+]}`
+
+func loadTestFlags(t *testing.T) *FeatureFlags {
+	t.Helper()
+	flags := NewFeatureFlags()
+	if err := flags.Load(strings.NewReader(testFlags)); err != nil {
+		t.Fatal(err)
+	}
+	return flags
+}
+
+func TestFeatureFlagRules(t *testing.T) {
+	flags := loadTestFlags(t)
+	if want := []string{"button-color", "kill-switch", "new-checkout"}; !reflect.DeepEqual(flags.Keys(), want) {
+		t.Fatalf("Keys() = %v, want %v", flags.Keys(), want)
+	}
+
+	cases := []struct {
+		name    string
+		ctx     FlagContext
+		variant string
+		reason  FlagReason
+		rule    string
+	}{
+		{"staff", FlagContext{Key: "u1", Attributes: map[string]string{"email": "ann@example.com"}}, "on", FlagReasonRuleMatch, "staff"},
+		{"blocked staff", FlagContext{Key: "u2", Attributes: map[string]string{"email": "bob@example.com", "country": "XX"}}, "off", FlagReasonRuleMatch, "blocked"},
+		{"anonymous", FlagContext{Key: "u3"}, "off", FlagReasonDefault, ""},
+	}
+	for _, tc := range cases {
+		got, err := flags.Evaluate("new-checkout", tc.ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Variant != tc.variant || got.Reason != tc.reason || got.Rule != tc.rule {
+			t.Errorf("%s: got %s/%s/%q, want %s/%s/%q", tc.name, got.Variant, got.Reason, got.Rule, tc.variant, tc.reason, tc.rule)
+		}
+	}
+
+	staff := cases[0].ctx
+	if !flags.Bool("new-checkout", staff, false) {
+		t.Fatal("Bool(new-checkout) = false for staff")
+	}
+	if flags.Bool("missing", staff, true) != true {
+		t.Fatal("Bool should return the fallback for missing flags")
+	}
+	if flags.Bool("button-color", staff, true) != true {
+		t.Fatal("Bool should return the fallback for non-boolean flags")
+	}
+
+	got, _ := flags.Evaluate("kill-switch", staff)
+	if got.Reason != FlagReasonDisabled || got.Value != false || got.RuleIndex != -1 {
+		t.Fatalf("kill-switch = %+v, want disabled/off", got)
+	}
+	if _, err := flags.Evaluate("missing", staff); !errors.Is(err, ErrFlagNotFound) {
+		t.Fatalf("err = %v, want ErrFlagNotFound", err)
+	}
+}
+
+func TestFeatureFlagRollout(t *testing.T) {
+	flags := loadTestFlags(t)
+	counts := make(map[string]int)
+	for i := 0; i < 2000; i++ {
+		ctx := FlagContext{Key: fmt.Sprintf("user-%d", i), Attributes: map[string]string{"plan": "pro"}}
+		first, _ := flags.Evaluate("new-checkout", ctx)
+		second, _ := flags.Evaluate("new-checkout", ctx)
+		if first != second {
+			t.Fatalf("evaluation for %s is not deterministic", ctx.Key)
+		}
+		if first.Reason != FlagReasonRollout || first.Rule != "beta" || first.RuleIndex != 2 {
+			t.Fatalf("evaluation = %+v, want rollout by beta", first)
+		}
+		counts[first.Variant]++
+	}
+	// 30% 分流，允许一定误差
+	if on := counts["on"]; on < 500 || on > 700 {
+		t.Fatalf("on = %d of 2000, want about 600", on)
+	}
+
+	color, _ := flags.Evaluate("button-color", FlagContext{Key: "user-1"})
+	if color.Value != "#0000ff" && color.Value != "#00ff00" {
+		t.Fatalf("button-color value = %v", color.Value)
+	}
+}
+
+func TestFeatureFlagValidation(t *testing.T) {
+	flags := loadTestFlags(t)
+	invalid := []string{
+		`{"flags":[null]}`,
+		`{"flags": [{"key": "a", "variants": {"on": true}, "default": "off"}]}`,
+		`{"flags": [{"key": "a", "variants": {"on": true}, "default": "on", "rules": [{"name": "r", "variant": "off"}]}]}`,
+		`{"flags": [{"key": "a", "variants": {"on": true}, "default": "on", "rules": [{"name": "r", "rollout": [{"variant": "on", "weight": 90}]}]}]}`,
+		`{"flags": [{"key": "a", "variants": {"on": true}, "default": "on", "rules": [{"name": "r", "variant": "on", "conditions": [{"attribute": "x", "op": "regex"}]}]}]}`,
+		`{"flags": [{"key": "a", "variants": {"on": true}, "default": "on"}, {"key": "a", "variants": {"on": true}, "default": "on"}]}`,
+	}
+	for _, data := range invalid {
+		if err := flags.Load(strings.NewReader(data)); !errors.Is(err, ErrInvalidFlag) {
+			t.Errorf("Load(%s) err = %v, want ErrInvalidFlag", data, err)
+		}
+	}
+	// 加载失败不影响原有定义
+	if len(flags.Keys()) != 3 {
+		t.Fatalf("Keys() = %v after failed loads", flags.Keys())
+	}
+}
+
+func TestFeatureFlagReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.json")
+	write := func(enabled bool) {
+		data := fmt.Sprintf(`{"flags": [{"key": "f", "enabled": %v, "variants": {"on": true, "off": false}, "default": "off",
+			"rules": [{"name": "all", "variant": "on"}]}]}`, enabled)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(false)
+
+	config := NewSingleton()
+	flags := config.Flags()
+	if config.Flags() != flags {
+		t.Fatal("Flags() should return the same instance")
+	}
+	if err := flags.Reload(); err == nil {
+		t.Fatal("Reload before LoadFile should fail")
+	}
+	if err := flags.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if flags.Bool("f", FlagContext{}, true) {
+		t.Fatal("f should be off")
+	}
+
+	write(true)
+	if err := flags.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if !flags.Bool("f", FlagContext{}, false) {
+		t.Fatal("f should be on after reload")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"flags": [`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := flags.Reload(); err == nil {
+		t.Fatal("Reload accepted a broken file")
+	}
+	if !flags.Bool("f", FlagContext{}, false) {
+		t.Fatal("failed reload should keep the previous definitions")
+	}
+}
+
+// writeFlagsFile 先写临时文件再重命名，Watch 不会读到写了一半的文件
+func writeFlagsFile(t *testing.T, path, data string, modTime time.Time) {
+	t.Helper()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(tmp, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFeatureFlagWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.json")
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	writeFlagsFile(t, path, `{"flags": []}`, start)
+
+	var mu sync.Mutex
+	var errs []error
+	reported := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(errs)
+	}
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("Watch did not %s", what)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	// 在 LoadFile 之前启动
+	flags := NewFeatureFlags()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		flags.Watch(ctx, 5*time.Millisecond, func(err error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		})
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+	if err := flags.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// 读到写了一半的文件，写完后修改时间没有变化（粗粒度的修改时间）
+	changed := start.Add(time.Second)
+	writeFlagsFile(t, path, `{"flags": [`, changed)
+	waitFor("report the broken file", func() bool { return reported() > 0 })
+
+	data := `{"flags": [{"key": "watched", "enabled": true, "variants": {"on": true}, "default": "on"}]}`
+	writeFlagsFile(t, path, data, changed)
+	waitFor("reload the finished file", func() bool { return flags.Bool("watched", FlagContext{}, false) })
+	if n := reported(); n != 1 {
+		t.Fatalf("onError called %d times, want 1", n)
+	}
+}
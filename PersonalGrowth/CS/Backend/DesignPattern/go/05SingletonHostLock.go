@@ -0,0 +1,105 @@
+package designpattern
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 主机级单例
+// GetSingleton 只能保证进程内唯一，HostLock 通过锁文件保证同一台机器上只有一个进程持有锁。
+// 锁文件中保存持有者的 PID。unix 上使用 flock，进程退出时内核会自动释放锁；
+// 其它平台以独占方式创建锁文件，通过 PID 判断锁是否已经失效，清理失效的锁只是尽力而为。
+
+var ErrHostLockHeld = errors.New("host lock held by another process")
+
+// HostLockHeldError 锁被其它进程持有，PID 为 0 表示无法读取持有者
+type HostLockHeldError struct {
+	Path string
+	PID  int
+}
+
+func (e *HostLockHeldError) Error() string {
+	if e.PID == 0 {
+		return fmt.Sprintf("%s: %v", e.Path, ErrHostLockHeld)
+	}
+	return fmt.Sprintf("%s: %v (pid %d)", e.Path, ErrHostLockHeld, e.PID)
+}
+
+func (e *HostLockHeldError) Is(target error) bool {
+	return target == ErrHostLockHeld
+}
+
+// HostLock 已获取的主机锁
+type HostLock struct {
+	path     string
+	file     *os.File
+	stalePID int
+
+	mu       sync.Mutex
+	released bool
+}
+
+// AcquireHostLock 获取 path 上的锁并写入当前进程的 PID，锁被占用时返回 *HostLockHeldError
+func AcquireHostLock(path string) (*HostLock, error) {
+	return acquireHostLock(path)
+}
+
+func (l *HostLock) Path() string {
+	return l.path
+}
+
+// StalePID 获取锁时如果发现已经退出的进程留下的锁，返回该进程的 PID，否则返回 0
+func (l *HostLock) StalePID() int {
+	return l.stalePID
+}
+
+// Release 释放锁，可以重复调用
+func (l *HostLock) Release() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.released {
+		return nil
+	}
+	l.released = true
+	return releaseHostLock(l)
+}
+
+// readLockPID 读取锁文件中的 PID，内容无效时返回 0
+func readLockPID(f *os.File) int {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0
+	}
+	data, err := io.ReadAll(io.LimitReader(f, 32))
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0
+	}
+	return pid
+}
+
+// writeLockPID 用当前进程的 PID 覆盖锁文件内容
+func writeLockPID(f *os.File) error {
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// staleLockPID 判断锁文件中记录的进程是否已经退出
+func staleLockPID(pid int) int {
+	if pid == 0 || pid == os.Getpid() || processAlive(pid) {
+		return 0
+	}
+	return pid
+}
@@ -0,0 +1,80 @@
+//go:build !unix
+
+package designpattern
+
+import (
+	"errors"
+	"os"
+)
+
+// 没有 flock 的平台以 O_EXCL 创建锁文件，进程异常退出后锁文件会留下，
+// 下一次获取时根据其中的 PID 判断并清理。
+//
+// 清理失效的锁只是尽力而为：两个进程同时发现同一个失效的 PID 时，后删除的一方可能删掉
+// 另一方刚创建的锁文件。创建后会检查 path 是否仍然是自己创建的文件，发现被替换时放弃，
+// 这缩小了竞争窗口，但不能保证只有一个持有者；需要严格互斥时应使用 unix 上的 flock 实现。
+func acquireHostLock(path string) (*HostLock, error) {
+	stale := 0
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			if err := writeLockPID(f); err != nil {
+				f.Close()
+				os.Remove(path)
+				return nil, err
+			}
+			if !ownsLockFile(f, path) {
+				// 另一个进程清理失效锁时删掉了我们的文件并创建了自己的
+				f.Close()
+				return nil, &HostLockHeldError{Path: path}
+			}
+			return &HostLock{path: path, file: f, stalePID: stale}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		existing, err := os.Open(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue // 持有者刚刚释放
+			}
+			return nil, err
+		}
+		pid := readLockPID(existing)
+		existing.Close()
+		if stale = staleLockPID(pid); stale == 0 {
+			return nil, &HostLockHeldError{Path: path, PID: pid}
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return nil, &HostLockHeldError{Path: path}
+}
+
+// ownsLockFile 检查 path 上的文件是否仍然是 f
+func ownsLockFile(f *os.File, path string) bool {
+	mine, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	current, err := os.Stat(path)
+	return err == nil && os.SameFile(mine, current)
+}
+
+func releaseHostLock(l *HostLock) error {
+	closeErr := l.file.Close()
+	removeErr := os.Remove(l.path)
+	return errors.Join(closeErr, removeErr)
+}
+
+// processAlive 在这些平台上 FindProcess 对不存在的进程返回错误
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
@@ -0,0 +1,158 @@
+package designpattern
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// 测试用的子进程：重新执行当前测试二进制，只运行 TestHostLockProcess
+// 获取锁后输出 "locked <stale pid>"，锁被占用时输出 "held <pid>"。
+// GO_HOST_LOCK_MODE=hold 时持有锁直到 stdin 关闭，=crash 时不释放锁直接退出。
+func TestHostLockProcess(t *testing.T) {
+	path := os.Getenv("GO_WANT_HOST_LOCK")
+	if path == "" {
+		t.Skip("helper process for host lock tests")
+	}
+	lock, err := AcquireHostLock(path)
+	var held *HostLockHeldError
+	if errors.As(err, &held) {
+		fmt.Printf("held %d\n", held.PID)
+		os.Exit(3)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("locked %d\n", lock.StalePID())
+	if os.Getenv("GO_HOST_LOCK_MODE") == "crash" {
+		os.Exit(0)
+	}
+	io.Copy(io.Discard, os.Stdin)
+	if err := lock.Release(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+type hostLockChild struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	line  string
+}
+
+func startHostLockChild(t *testing.T, path, mode string) *hostLockChild {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHostLockProcess$")
+	cmd.Env = append(os.Environ(), "GO_WANT_HOST_LOCK="+path, "GO_HOST_LOCK_MODE="+mode)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		t.Fatalf("read child output: %v", err)
+	}
+	child := &hostLockChild{cmd: cmd, stdin: stdin, line: strings.TrimSpace(line)}
+	t.Cleanup(func() { child.stop() })
+	return child
+}
+
+// stop 关闭 stdin 让子进程释放锁并退出
+func (c *hostLockChild) stop() error {
+	c.stdin.Close()
+	return c.cmd.Wait()
+}
+
+func TestHostLockExcludesOtherProcesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "batch.lock")
+	lock, err := AcquireHostLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if got := strings.TrimSpace(string(data)); got != strconv.Itoa(os.Getpid()) {
+		t.Fatalf("lock file = %q, want our pid %d", got, os.Getpid())
+	}
+
+	child := startHostLockChild(t, path, "hold")
+	if want := fmt.Sprintf("held %d", os.Getpid()); child.line != want {
+		t.Fatalf("child = %q, want %q", child.line, want)
+	}
+
+	// 同一进程内第二次获取也会失败
+	if _, err := AcquireHostLock(path); !errors.Is(err, ErrHostLockHeld) {
+		t.Fatalf("second acquire err = %v, want ErrHostLockHeld", err)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatalf("second Release = %v", err)
+	}
+
+	child = startHostLockChild(t, path, "hold")
+	if child.line != "locked 0" {
+		t.Fatalf("child = %q, want locked 0", child.line)
+	}
+	_, err = AcquireHostLock(path)
+	var held *HostLockHeldError
+	if !errors.As(err, &held) || held.PID != child.cmd.Process.Pid {
+		t.Fatalf("err = %v, want held by child %d", err, child.cmd.Process.Pid)
+	}
+
+	if err := child.stop(); err != nil {
+		t.Fatal(err)
+	}
+	lock, err = AcquireHostLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lock.StalePID() != 0 {
+		t.Fatalf("StalePID() = %d after a clean release", lock.StalePID())
+	}
+	lock.Release()
+}
+
+func TestHostLockStale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "batch.lock")
+	child := startHostLockChild(t, path, "crash")
+	if child.line != "locked 0" {
+		t.Fatalf("child = %q, want locked 0", child.line)
+	}
+	pid := child.cmd.Process.Pid
+	if err := child.stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := AcquireHostLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Release()
+	if lock.StalePID() != pid {
+		t.Fatalf("StalePID() = %d, want crashed child %d", lock.StalePID(), pid)
+	}
+	if lock.Path() != path {
+		t.Fatalf("Path() = %q, want %q", lock.Path(), path)
+	}
+}
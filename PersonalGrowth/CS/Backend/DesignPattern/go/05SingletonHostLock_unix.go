@@ -0,0 +1,46 @@
+//go:build unix
+
+package designpattern
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+func acquireHostLock(path string) (*HostLock, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		pid := readLockPID(f)
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, &HostLockHeldError{Path: path, PID: pid}
+		}
+		return nil, &os.PathError{Op: "flock", Path: path, Err: err}
+	}
+
+	// 拿到 flock 后文件中仍有其它进程的 PID，说明持有者没有正常释放就退出了
+	stale := staleLockPID(readLockPID(f))
+	if err := writeLockPID(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &HostLock{path: path, file: f, stalePID: stale}, nil
+}
+
+// releaseHostLock 清空 PID 后解锁，不删除锁文件：
+// 删除已加锁的文件会让等待中的进程锁住一个已经不存在的文件
+func releaseHostLock(l *HostLock) error {
+	truncErr := l.file.Truncate(0)
+	unlockErr := syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
+	closeErr := l.file.Close()
+	return errors.Join(truncErr, unlockErr, closeErr)
+}
+
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
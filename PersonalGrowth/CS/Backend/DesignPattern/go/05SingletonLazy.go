@@ -0,0 +1,60 @@
+package designpattern
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Lazy 延迟初始化的单例
+// 与 sync.Once 不同，初始化函数可以返回错误，失败后下一次 Get 会重新初始化。
+type Lazy[T any] struct {
+	init  func() (T, error)
+	mu    sync.Mutex // 保证同一时间只有一个初始化在执行
+	value atomic.Pointer[T]
+}
+
+func NewLazy[T any](init func() (T, error)) *Lazy[T] {
+	return &Lazy[T]{init: init}
+}
+
+// Get 返回已初始化的值，尚未初始化时调用初始化函数
+// 初始化失败时返回错误且不保存结果，等待中的调用会各自重试
+func (l *Lazy[T]) Get() (T, error) {
+	if v := l.value.Load(); v != nil {
+		return *v, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if v := l.value.Load(); v != nil {
+		return *v, nil
+	}
+	value, err := l.init()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	l.value.Store(&value)
+	return value, nil
+}
+
+// MustGet 与 Get 相同，初始化失败时 panic
+func (l *Lazy[T]) MustGet() T {
+	value, err := l.Get()
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+// Initialized 判断是否已经成功初始化
+func (l *Lazy[T]) Initialized() bool {
+	return l.value.Load() != nil
+}
+
+// Reset 丢弃已初始化的值，下一次 Get 会重新初始化，仅用于测试
+func (l *Lazy[T]) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.value.Store(nil)
+}
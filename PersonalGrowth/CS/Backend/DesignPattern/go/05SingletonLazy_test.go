@@ -0,0 +1,120 @@
+package designpattern
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLazyRetry(t *testing.T) {
+	failure := errors.New("config not ready")
+	var calls int
+	lazy := NewLazy(func() (*Singleton, error) {
+		calls++
+		if calls < 3 {
+			return nil, failure
+		}
+		return &Singleton{Name: "config"}, nil
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, err := lazy.Get(); !errors.Is(err, failure) {
+			t.Fatalf("attempt %d err = %v, want failure", i+1, err)
+		}
+		if lazy.Initialized() {
+			t.Fatal("Initialized() = true after a failed init")
+		}
+	}
+
+	first, err := lazy.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second := lazy.MustGet()
+	if first != second || first.Name != "config" || calls != 3 {
+		t.Fatalf("got %p and %p after %d calls", first, second, calls)
+	}
+	if !lazy.Initialized() {
+		t.Fatal("Initialized() = false after success")
+	}
+
+	lazy.Reset()
+	if lazy.Initialized() {
+		t.Fatal("Initialized() = true after Reset")
+	}
+	third := lazy.MustGet()
+	if third == first || calls != 4 {
+		t.Fatalf("Reset did not reinitialize: same=%v calls=%d", third == first, calls)
+	}
+}
+
+func TestLazyMustGetPanics(t *testing.T) {
+	failure := errors.New("boom")
+	lazy := NewLazy(func() (int, error) { return 0, failure })
+	defer func() {
+		if r := recover(); r != failure {
+			t.Fatalf("recover() = %v, want %v", r, failure)
+		}
+	}()
+	lazy.MustGet()
+}
+
+func TestLazyConcurrent(t *testing.T) {
+	var calls atomic.Int32
+	lazy := NewLazy(func() (*Singleton, error) {
+		if calls.Add(1) <= 5 {
+			return nil, errors.New("transient")
+		}
+		return &Singleton{}, nil
+	})
+
+	const goroutines = 64
+	results := make([]*Singleton, goroutines)
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for {
+				value, err := lazy.Get()
+				if err == nil {
+					results[i] = value
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i, value := range results {
+		if value != results[0] {
+			t.Fatalf("goroutine %d got a different instance", i)
+		}
+	}
+	if got := calls.Load(); got != 6 {
+		t.Fatalf("init called %d times, want 6", got)
+	}
+}
+
+func TestLazyConcurrentReset(t *testing.T) {
+	lazy := NewLazy(func() (*Singleton, error) { return &Singleton{}, nil })
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if lazy.MustGet() == nil {
+					t.Error("MustGet returned nil")
+					return
+				}
+				lazy.Initialized()
+				if i == 0 && j%10 == 0 {
+					lazy.Reset()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,325 @@
+package designpattern
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// 生命周期管理
+// LifecycleManager 是按名称管理组件的多例注册表。启动顺序由声明的依赖决定，
+// 停止时按启动的相反顺序进行，每个组件可以单独设置超时。
+
+var (
+	ErrComponentExists    = errors.New("component already registered")
+	ErrComponentNotFound  = errors.New("component not found")
+	ErrMissingDependency  = errors.New("missing dependency")
+	ErrLifecycleStarted   = errors.New("lifecycle already started")
+	ErrComponentTimeout   = errors.New("component timed out")
+	ErrComponentWrongType = errors.New("component has wrong type")
+)
+
+// DefaultComponentTimeout 未设置超时的组件启动和停止的超时
+const DefaultComponentTimeout = 10 * time.Second
+
+// Component 由受管理的组件实现
+type Component interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+// ComponentHooks 用函数实现 Component，未设置的钩子什么也不做
+type ComponentHooks struct {
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+func (h ComponentHooks) Start(ctx context.Context) error {
+	if h.OnStart == nil {
+		return nil
+	}
+	return h.OnStart(ctx)
+}
+
+func (h ComponentHooks) Stop(ctx context.Context) error {
+	if h.OnStop == nil {
+		return nil
+	}
+	return h.OnStop(ctx)
+}
+
+type managedComponent struct {
+	name         string
+	component    Component
+	dependsOn    []string
+	startTimeout time.Duration
+	stopTimeout  time.Duration
+}
+
+// ComponentOption 注册组件时的选项
+type ComponentOption func(*managedComponent)
+
+// WithDependsOn 声明依赖的组件，它们会先于该组件启动、晚于该组件停止
+func WithDependsOn(names ...string) ComponentOption {
+	return func(c *managedComponent) {
+		c.dependsOn = append(c.dependsOn, names...)
+	}
+}
+
+func WithStartTimeout(timeout time.Duration) ComponentOption {
+	return func(c *managedComponent) {
+		c.startTimeout = timeout
+	}
+}
+
+func WithStopTimeout(timeout time.Duration) ComponentOption {
+	return func(c *managedComponent) {
+		c.stopTimeout = timeout
+	}
+}
+
+// LifecycleManager 可并发使用，组件的钩子中可以调用 Get 获取其它组件
+type LifecycleManager struct {
+	mu         sync.Mutex // 保护 components、registered 和 running
+	components map[string]*managedComponent
+	registered []string // 注册顺序，依赖相同时按注册顺序启动
+	running    bool
+
+	lifecycleMu sync.Mutex // 串行化 Start 和 Stop，保护 started
+	started     []*managedComponent
+
+	// ShutdownTimeout Run 收到信号后停止所有组件的总超时
+	ShutdownTimeout time.Duration
+}
+
+func NewLifecycleManager() *LifecycleManager {
+	return &LifecycleManager{
+		components:      make(map[string]*managedComponent),
+		ShutdownTimeout: 30 * time.Second,
+	}
+}
+
+// Register 注册组件，启动后不能再注册
+func (m *LifecycleManager) Register(name string, component Component, opts ...ComponentOption) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.running {
+		return ErrLifecycleStarted
+	}
+	if _, ok := m.components[name]; ok {
+		return fmt.Errorf("%w: %s", ErrComponentExists, name)
+	}
+	c := &managedComponent{
+		name:         name,
+		component:    component,
+		startTimeout: DefaultComponentTimeout,
+		stopTimeout:  DefaultComponentTimeout,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	m.components[name] = c
+	m.registered = append(m.registered, name)
+	return nil
+}
+
+// Get 按名称返回组件
+func (m *LifecycleManager) Get(name string) (Component, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.components[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrComponentNotFound, name)
+	}
+	return c.component, nil
+}
+
+// GetComponent 按名称返回指定类型的组件
+func GetComponent[T Component](m *LifecycleManager, name string) (T, error) {
+	var zero T
+	component, err := m.Get(name)
+	if err != nil {
+		return zero, err
+	}
+	typed, ok := component.(T)
+	if !ok {
+		return zero, fmt.Errorf("%w: %s is %T, want %T", ErrComponentWrongType, name, component, zero)
+	}
+	return typed, nil
+}
+
+// Names 返回所有组件名称，按字母排序
+func (m *LifecycleManager) Names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := append([]string(nil), m.registered...)
+	sort.Strings(names)
+	return names
+}
+
+// Order 返回启动顺序
+func (m *LifecycleManager) Order() ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order()
+}
+
+func (m *LifecycleManager) order() ([]string, error) {
+	deps := make(map[string][]string, len(m.components))
+	for name, c := range m.components {
+		deps[name] = c.dependsOn
+	}
+	return dependencyOrder(m.registered, deps)
+}
+
+// dependencyOrder 按依赖对 names 拓扑排序，依赖之间没有先后关系时保持 names 中的顺序
+// deps 必须包含所有名称，依赖不在 deps 中时返回 ErrMissingDependency
+func dependencyOrder(names []string, deps map[string][]string) ([]string, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	var order, path []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			i := len(path) - 1
+			for path[i] != name {
+				i--
+			}
+			return fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(append(path[i:], name), " -> "))
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range deps[name] {
+			if _, ok := deps[dep]; !ok {
+				return fmt.Errorf("%w: %s depends on %s", ErrMissingDependency, name, dep)
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		order = append(order, name)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// Start 按依赖顺序启动所有组件，某个组件启动失败时停止已经启动的组件并返回错误
+func (m *LifecycleManager) Start(ctx context.Context) error {
+	m.lifecycleMu.Lock()
+	defer m.lifecycleMu.Unlock()
+
+	m.mu.Lock()
+	if m.running {
+		m.mu.Unlock()
+		return ErrLifecycleStarted
+	}
+	order, err := m.order()
+	if err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	components := make([]*managedComponent, len(order))
+	for i, name := range order {
+		components[i] = m.components[name]
+	}
+	m.running = true
+	m.mu.Unlock()
+
+	for _, c := range components {
+		if err := callWithTimeout(ctx, c.startTimeout, c.component.Start); err != nil {
+			err = fmt.Errorf("start %s: %w", c.name, err)
+			return errors.Join(err, m.stop(context.WithoutCancel(ctx)))
+		}
+		m.started = append(m.started, c)
+		emitf("LifecycleManager", "started %s", c.name)
+	}
+	return nil
+}
+
+// Stop 按启动的相反顺序停止组件，某个组件停止失败不影响其它组件，返回所有错误
+func (m *LifecycleManager) Stop(ctx context.Context) error {
+	m.lifecycleMu.Lock()
+	defer m.lifecycleMu.Unlock()
+	return m.stop(ctx)
+}
+
+func (m *LifecycleManager) stop(ctx context.Context) error {
+	var errs []error
+	for i := len(m.started) - 1; i >= 0; i-- {
+		c := m.started[i]
+		if err := callWithTimeout(ctx, c.stopTimeout, c.component.Stop); err != nil {
+			errs = append(errs, fmt.Errorf("stop %s: %w", c.name, err))
+			continue
+		}
+		emitf("LifecycleManager", "stopped %s", c.name)
+	}
+	m.started = nil
+
+	m.mu.Lock()
+	m.running = false
+	m.mu.Unlock()
+	return errors.Join(errs...)
+}
+
+// Run 启动所有组件，直到 ctx 结束或收到 SIGINT/SIGTERM 后停止所有组件
+func (m *LifecycleManager) Run(ctx context.Context) error {
+	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	if err := m.Start(ctx); err != nil {
+		return err
+	}
+	<-ctx.Done()
+
+	stopCtx, stopCancel := context.WithTimeout(context.WithoutCancel(ctx), m.ShutdownTimeout)
+	defer stopCancel()
+	return m.Stop(stopCtx)
+}
+
+// callWithTimeout 在超时内调用 fn，fn 忽略 ctx 时也会按时返回。
+// 只有这一步自己的超时到期时才包装为 ErrComponentTimeout，调用方的 ctx 结束时原样返回 ctx.Err()。
+func callWithTimeout(ctx context.Context, timeout time.Duration, fn func(context.Context) error) error {
+	parent := ctx
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- fn(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+		if err == nil {
+			return nil
+		}
+	case <-ctx.Done():
+		if err := parent.Err(); err != nil {
+			return err
+		}
+		err = ctx.Err()
+	}
+	if parent.Err() == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrComponentTimeout, err)
+	}
+	return err
+}
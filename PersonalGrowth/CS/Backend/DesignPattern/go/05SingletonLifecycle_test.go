@@ -0,0 +1,288 @@
+package designpattern
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingComponent 记录启动和停止的顺序
+type recordingComponent struct {
+	name     string
+	log      *[]string
+	mu       *sync.Mutex
+	startErr error
+	stopErr  error
+}
+
+func (c *recordingComponent) record(event string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.log = append(*c.log, event+" "+c.name)
+}
+
+func (c *recordingComponent) Start(ctx context.Context) error {
+	if c.startErr != nil {
+		return c.startErr
+	}
+	c.record("start")
+	return nil
+}
+
+func (c *recordingComponent) Stop(ctx context.Context) error {
+	c.record("stop")
+	return c.stopErr
+}
+
+type componentLog struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (l *componentLog) component(name string) *recordingComponent {
+	return &recordingComponent{name: name, log: &l.events, mu: &l.mu}
+}
+
+func TestLifecycleOrder(t *testing.T) {
+	recorder := recordOutput(t)
+	var log componentLog
+	m := NewLifecycleManager()
+	for _, reg := range []struct {
+		name string
+		deps []string
+	}{
+		{"api", []string{"cache", "db"}},
+		{"cache", []string{"config"}},
+		{"db", []string{"config"}},
+		{"config", nil},
+	} {
+		if err := m.Register(reg.name, log.component(reg.name), WithDependsOn(reg.deps...)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	order, err := m.Order()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"config", "cache", "db", "api"}; !reflect.DeepEqual(order, want) {
+		t.Fatalf("Order() = %v, want %v", order, want)
+	}
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Start(context.Background()); !errors.Is(err, ErrLifecycleStarted) {
+		t.Fatalf("second Start err = %v, want ErrLifecycleStarted", err)
+	}
+	if err := m.Register("late", log.component("late")); !errors.Is(err, ErrLifecycleStarted) {
+		t.Fatalf("Register after start err = %v, want ErrLifecycleStarted", err)
+	}
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"start config", "start cache", "start db", "start api",
+		"stop api", "stop db", "stop cache", "stop config",
+	}
+	if !reflect.DeepEqual(log.events, want) {
+		t.Fatalf("events = %v, want %v", log.events, want)
+	}
+	assertMessages(t, recorder,
+		"started config", "started cache", "started db", "started api",
+		"stopped api", "stopped db", "stopped cache", "stopped config",
+	)
+}
+
+func TestLifecycleRegistry(t *testing.T) {
+	var log componentLog
+	m := NewLifecycleManager()
+	db := log.component("db")
+	if err := m.Register("db", db); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Register("db", db); !errors.Is(err, ErrComponentExists) {
+		t.Fatalf("err = %v, want ErrComponentExists", err)
+	}
+	if err := m.Register("hooks", ComponentHooks{}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := GetComponent[*recordingComponent](m, "db")
+	if err != nil || got != db {
+		t.Fatalf("GetComponent(db) = %v, %v", got, err)
+	}
+	if _, err := GetComponent[*recordingComponent](m, "hooks"); !errors.Is(err, ErrComponentWrongType) {
+		t.Fatalf("err = %v, want ErrComponentWrongType", err)
+	}
+	if _, err := m.Get("missing"); !errors.Is(err, ErrComponentNotFound) {
+		t.Fatalf("err = %v, want ErrComponentNotFound", err)
+	}
+	if want := []string{"db", "hooks"}; !reflect.DeepEqual(m.Names(), want) {
+		t.Fatalf("Names() = %v, want %v", m.Names(), want)
+	}
+}
+
+func TestLifecycleDependencyErrors(t *testing.T) {
+	m := NewLifecycleManager()
+	m.Register("a", ComponentHooks{}, WithDependsOn("b"))
+	m.Register("b", ComponentHooks{}, WithDependsOn("c"))
+	m.Register("c", ComponentHooks{}, WithDependsOn("a"))
+	_, err := m.Order()
+	if !errors.Is(err, ErrDependencyCycle) || err.Error() != "dependency cycle: a -> b -> c -> a" {
+		t.Fatalf("err = %v, want cycle a -> b -> c -> a", err)
+	}
+
+	m = NewLifecycleManager()
+	m.Register("api", ComponentHooks{}, WithDependsOn("db"))
+	if err := m.Start(context.Background()); !errors.Is(err, ErrMissingDependency) {
+		t.Fatalf("err = %v, want ErrMissingDependency", err)
+	}
+}
+
+func TestLifecycleStartFailureRollsBack(t *testing.T) {
+	var log componentLog
+	failure := errors.New("connection refused")
+	m := NewLifecycleManager()
+	m.Register("config", log.component("config"))
+	db := log.component("db")
+	db.startErr = failure
+	m.Register("db", db, WithDependsOn("config"))
+	m.Register("api", log.component("api"), WithDependsOn("db"))
+
+	if err := m.Start(context.Background()); !errors.Is(err, failure) {
+		t.Fatalf("err = %v, want failure", err)
+	}
+	if want := []string{"start config", "stop config"}; !reflect.DeepEqual(log.events, want) {
+		t.Fatalf("events = %v, want %v", log.events, want)
+	}
+
+	// 失败后可以修复并重新启动
+	db.startErr = nil
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	m.Stop(context.Background())
+}
+
+func TestLifecycleTimeouts(t *testing.T) {
+	var log componentLog
+	stopFailure := errors.New("flush failed")
+	m := NewLifecycleManager()
+	m.Register("config", log.component("config"))
+	broken := log.component("broken")
+	broken.stopErr = stopFailure
+	m.Register("broken", broken, WithDependsOn("config"))
+	m.Register("slow", ComponentHooks{
+		OnStop: func(ctx context.Context) error {
+			time.Sleep(time.Second) // 忽略 ctx
+			return nil
+		},
+	}, WithDependsOn("broken"), WithStopTimeout(20*time.Millisecond))
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	err := m.Stop(context.Background())
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("Stop took %v, want the slow component to time out", elapsed)
+	}
+	if !errors.Is(err, ErrComponentTimeout) || !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, stopFailure) {
+		t.Fatalf("err = %v, want timeout and stop failure", err)
+	}
+	// 其它组件仍然会被停止
+	if want := []string{"start config", "start broken", "stop broken", "stop config"}; !reflect.DeepEqual(log.events, want) {
+		t.Fatalf("events = %v, want %v", log.events, want)
+	}
+
+	m = NewLifecycleManager()
+	m.Register("hang", ComponentHooks{
+		OnStart: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}, WithStartTimeout(10*time.Millisecond))
+	if err := m.Start(context.Background()); !errors.Is(err, ErrComponentTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want ErrComponentTimeout", err)
+	}
+
+	// 调用方取消不是组件超时
+	m = NewLifecycleManager()
+	m.Register("ignore", ComponentHooks{
+		OnStart: func(context.Context) error {
+			time.Sleep(time.Second) // 忽略 ctx
+			return nil
+		},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := m.Start(ctx); !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrComponentTimeout) {
+		t.Fatalf("err = %v, want the caller's DeadlineExceeded only", err)
+	}
+}
+
+func TestLifecycleRunContext(t *testing.T) {
+	var log componentLog
+	m := NewLifecycleManager()
+	m.Register("db", log.component("db"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- m.Run(ctx) }()
+	for {
+		log.mu.Lock()
+		started := len(log.events) > 0
+		log.mu.Unlock()
+		if started {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"start db", "stop db"}; !reflect.DeepEqual(log.events, want) {
+		t.Fatalf("events = %v, want %v", log.events, want)
+	}
+}
+
+func TestLifecycleRunSignal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sending os.Interrupt is not supported on windows")
+	}
+	var log componentLog
+	m := NewLifecycleManager()
+	m.Register("db", log.component("db"))
+	// Run 在启动组件之前已经注册了信号，这里发送的 SIGINT 会被 Run 接收
+	m.Register("signal", ComponentHooks{
+		OnStart: func(ctx context.Context) error {
+			p, err := os.FindProcess(os.Getpid())
+			if err != nil {
+				return err
+			}
+			return p.Signal(os.Interrupt)
+		},
+	}, WithDependsOn("db"))
+
+	done := make(chan error, 1)
+	go func() { done <- m.Run(context.Background()) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not stop after SIGINT")
+	}
+	if want := []string{"start db", "stop db"}; !reflect.DeepEqual(log.events, want) {
+		t.Fatalf("events = %v, want %v", log.events, want)
+	}
+}
@@ -1,18 +1,26 @@
 package designpattern
 
 import (
-	"fmt"
 	"testing"
 )
 
 func TestSingleton(t *testing.T) {
-	singleton := GetSingleton()
+	recorder := recordOutput(t)
+	singleton.Reset()
+	t.Cleanup(singleton.Reset)
+	if singleton.Initialized() {
+		t.Fatal("singleton initialized before first use")
+	}
+	singleton1 := GetSingleton()
 
-	singleton.SetName("Singleton")
-	singleton.PrintName()
+	singleton1.SetName("Singleton")
+	singleton1.PrintName()
 
 	singleton2 := GetSingleton()
 	singleton2.PrintName()
 
-	fmt.Println("singleton == singleton2:", singleton == singleton2)
+	if singleton1 != singleton2 {
+		t.Fatal("GetSingleton returned different instances")
+	}
+	assertMessages(t, recorder, "Singleton", "Singleton")
 }
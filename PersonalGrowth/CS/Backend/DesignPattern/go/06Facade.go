@@ -1,68 +1,420 @@
 package designpattern
 
-// 子系统1：CPU
-type CPU struct{}
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
 
-func (c *CPU) Start() {
-	println("CPU is starting")
+// 外观模式
+// 子系统组成一台简单的计算机：硬盘从文件读取程序镜像，内存把镜像装入地址空间，
+// CPU 执行其中的指令（见 06FacadeVM.go）。子系统记录自己的状态并拒绝非法操作，
+// 例如内存未加载时执行、重复关机。所有操作（包括失败的操作）都会记录到带时间戳的 Timeline 中。
+
+// SubsystemState 子系统的状态
+type SubsystemState string
+
+const (
+	StateOff       SubsystemState = "off"
+	StateOn        SubsystemState = "on"
+	StateExecuting SubsystemState = "executing"
+	StateHalted    SubsystemState = "halted"
+	StateEmpty     SubsystemState = "empty"
+	StateLoaded    SubsystemState = "loaded"
+	StateParked    SubsystemState = "parked"
+	StateSpinning  SubsystemState = "spinning"
+)
+
+var (
+	ErrPoweredOff       = errors.New("powered off")
+	ErrAlreadyRunning   = errors.New("already running")
+	ErrMemoryNotLoaded  = errors.New("memory not loaded")
+	ErrMemoryLoaded     = errors.New("memory already loaded")
+	ErrDriveParked      = errors.New("hard drive parked")
+	ErrImageTooLarge    = errors.New("program image too large")
+	ErrComputerNotReady = errors.New("computer not started")
+)
+
+// SubsystemError 子系统拒绝的操作，State 为操作时的状态
+type SubsystemError struct {
+	Component string
+	Op        string
+	State     SubsystemState
+	Err       error
+}
+
+func (e *SubsystemError) Error() string {
+	return fmt.Sprintf("%s %s (%s): %v", e.Component, e.Op, e.State, e.Err)
+}
+
+func (e *SubsystemError) Unwrap() error {
+	return e.Err
+}
+
+// TimelineEvent 一次子系统操作，失败时 Err 不为 nil 且 From 等于 To
+type TimelineEvent struct {
+	Time      time.Time
+	Component string
+	Op        string
+	From, To  SubsystemState
+	Err       error
+}
+
+// Timeline 按时间顺序记录子系统操作，可并发使用，nil 表示不记录
+type Timeline struct {
+	mu     sync.Mutex
+	events []TimelineEvent
+}
+
+func NewTimeline() *Timeline {
+	return &Timeline{}
+}
+
+func (t *Timeline) record(component, op string, from, to SubsystemState, err error) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, TimelineEvent{
+		Time:      time.Now(),
+		Component: component,
+		Op:        op,
+		From:      from,
+		To:        to,
+		Err:       err,
+	})
+}
+
+// Events 返回已记录事件的副本
+func (t *Timeline) Events() []TimelineEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TimelineEvent(nil), t.events...)
+}
+
+// subsystem 子系统的公共部分，零值处于 initial 状态
+type subsystem struct {
+	mu       sync.Mutex
+	state    SubsystemState
+	timeline *Timeline
+}
+
+func (s *subsystem) current(initial SubsystemState) SubsystemState {
+	if s.state == "" {
+		s.state = initial
+	}
+	return s.state
+}
+
+// transition 在 s.mu 已加锁时调用，check 返回错误时状态不变
+func (s *subsystem) transition(component, op string, initial, to SubsystemState, check func(SubsystemState) error) error {
+	from := s.current(initial)
+	if err := check(from); err != nil {
+		err = &SubsystemError{Component: component, Op: op, State: from, Err: err}
+		s.timeline.record(component, op, from, from, err)
+		return err
+	}
+	s.state = to
+	s.timeline.record(component, op, from, to, nil)
+	return nil
+}
+
+// attach 子系统还没有 Timeline 时使用 t
+func (s *subsystem) attach(t *Timeline) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.timeline == nil {
+		s.timeline = t
+	}
+}
+
+// 子系统1：CPU，执行相关的方法见 06FacadeVM.go
+type CPU struct {
+	subsystem
+	memory *Memory
+	regs   Registers
+	pc     int
+	steps  int
+	trace  func(TraceEntry)
+}
+
+func (c *CPU) State() SubsystemState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.current(StateOff)
+}
+
+func (c *CPU) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := c.transition("CPU", "start", StateOff, StateOn, func(from SubsystemState) error {
+		if from != StateOff {
+			return ErrAlreadyRunning
+		}
+		return nil
+	})
+	if err == nil {
+		emit("CPU", "CPU is starting")
+	}
+	return err
+}
+
+func (c *CPU) Shutdown() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := c.transition("CPU", "shutdown", StateOff, StateOff, func(from SubsystemState) error {
+		if from == StateOff {
+			return ErrPoweredOff
+		}
+		return nil
+	})
+	if err == nil {
+		c.memory = nil
+		emit("CPU", "CPU is shutting down")
+	}
+	return err
+}
+
+// MemorySize 内存的字节数，地址范围是 [0, MemorySize)
+const MemorySize = 1 << 15
+
+// 子系统2：Memory，字节寻址的地址空间，读写方法见 06FacadeVM.go
+type Memory struct {
+	subsystem
+	data []byte
+}
+
+func (m *Memory) State() SubsystemState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.current(StateEmpty)
 }
 
-func (c *CPU) Execute() {
-	println("CPU is executing")
+// Load 把程序镜像装入地址 0，其余字节为 0
+func (m *Memory) Load(image []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	err := m.transition("Memory", "load", StateEmpty, StateLoaded, func(from SubsystemState) error {
+		if from == StateLoaded {
+			return ErrMemoryLoaded
+		}
+		if len(image) > MemorySize {
+			return fmt.Errorf("%w: %d bytes, memory has %d", ErrImageTooLarge, len(image), MemorySize)
+		}
+		return nil
+	})
+	if err == nil {
+		m.data = make([]byte, MemorySize)
+		copy(m.data, image)
+		emit("Memory", "Memory is loading")
+	}
+	return err
 }
 
-func (c *CPU) Shutdown() {
-	println("CPU is shutting down")
+func (m *Memory) Unload() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	err := m.transition("Memory", "unload", StateEmpty, StateEmpty, func(from SubsystemState) error {
+		if from != StateLoaded {
+			return ErrMemoryNotLoaded
+		}
+		return nil
+	})
+	if err == nil {
+		m.data = nil
+		emit("Memory", "Memory is unloading")
+	}
+	return err
 }
 
-// 子系统2：Memory
-type Memory struct{}
+// 子系统3：HardDrive，零值没有镜像文件，读出的镜像为空
+type HardDrive struct {
+	subsystem
+	path  string
+	image []byte
+}
 
-func (m *Memory) Load() {
-	println("Memory is loading")
+// NewHardDrive 创建从 path 读取程序镜像的硬盘
+func NewHardDrive(path string) *HardDrive {
+	return &HardDrive{path: path}
 }
 
-func (m *Memory) Unload() {
-	println("Memory is unloading")
+func (h *HardDrive) State() SubsystemState {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.current(StateParked)
 }
 
-// 子系统3：HardDrive
-type HardDrive struct{}
+// Read 读取程序镜像，硬盘停转时会先启动，读取失败时停转
+func (h *HardDrive) Read() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	err := h.transition("HardDrive", "read", StateParked, StateSpinning, func(SubsystemState) error {
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	h.image = nil
+	if h.path != "" {
+		image, err := os.ReadFile(h.path)
+		if err != nil {
+			err = &SubsystemError{Component: "HardDrive", Op: "read", State: StateSpinning, Err: err}
+			h.state = StateParked
+			h.timeline.record("HardDrive", "read", StateSpinning, StateParked, err)
+			return err
+		}
+		h.image = image
+	}
+	emit("HardDrive", "HardDrive is reading")
+	return nil
+}
+
+// Image 返回上一次 Read 读取的程序镜像
+func (h *HardDrive) Image() []byte {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]byte(nil), h.image...)
+}
 
-func (h *HardDrive) Read() {
-	println("HardDrive is reading")
+// Write 写入数据，硬盘必须正在运转
+func (h *HardDrive) Write() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	err := h.transition("HardDrive", "write", StateParked, StateSpinning, func(from SubsystemState) error {
+		if from != StateSpinning {
+			return ErrDriveParked
+		}
+		return nil
+	})
+	if err == nil {
+		emit("HardDrive", "HardDrive is writing")
+	}
+	return err
 }
 
-func (h *HardDrive) Write() {
-	println("HardDrive is writing")
+// Park 停转硬盘
+func (h *HardDrive) Park() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	err := h.transition("HardDrive", "park", StateParked, StateParked, func(from SubsystemState) error {
+		if from != StateSpinning {
+			return ErrDriveParked
+		}
+		return nil
+	})
+	if err == nil {
+		emit("HardDrive", "HardDrive is parking")
+	}
+	return err
 }
 
-// ComputerFacade 提供了一个统一的接口
+// ComputerFacade 提供了一个统一的接口，Start 和 Shutdown 可以并发调用
+// 子系统由 BootOrchestrator 按依赖启动：CPU 通电后硬盘读取镜像，内存装入镜像，CPU 进入执行状态，
+// 启动完成后再执行程序直到停机
 type ComputerFacade struct {
 	cpu       *CPU
 	memory    *Memory
 	hardDrive *HardDrive
+	timeline  *Timeline
+	boot      *BootOrchestrator
 }
 
 // NewComputerFacade 创建外观
 func NewComputerFacade() *ComputerFacade {
+	return NewComputerFacadeWith(&CPU{}, &Memory{}, &HardDrive{})
+}
+
+// NewComputerFacadeWith 使用给定的子系统创建外观，没有 Timeline 的子系统会记录到外观的 Timeline
+func NewComputerFacadeWith(cpu *CPU, memory *Memory, hardDrive *HardDrive) *ComputerFacade {
+	timeline := NewTimeline()
+	cpu.attach(timeline)
+	memory.attach(timeline)
+	hardDrive.attach(timeline)
+
+	boot := NewBootOrchestrator()
+	boot.Add("cpu", ComponentHooks{
+		OnStart: ignoreContext(cpu.Start),
+		OnStop:  ignoreContext(cpu.Shutdown),
+	})
+	boot.Add("hardDrive", ComponentHooks{
+		OnStart: ignoreContext(hardDrive.Read),
+		OnStop: func(context.Context) error {
+			// 把数据写回硬盘后停转
+			if err := hardDrive.Write(); err != nil {
+				return err
+			}
+			return hardDrive.Park()
+		},
+	}, WithDependsOn("cpu"))
+	boot.Add("memory", ComponentHooks{
+		OnStart: func(context.Context) error { return memory.Load(hardDrive.Image()) },
+		OnStop:  ignoreContext(memory.Unload),
+	}, WithDependsOn("hardDrive"))
+	// 启动只让 CPU 进入执行状态，程序在启动完成后由 StartContext 运行，不受组件的启动超时限制
+	boot.Add("execute", ComponentHooks{
+		OnStart: ignoreContext(func() error { return cpu.Begin(memory) }),
+	}, WithDependsOn("memory"))
+
 	return &ComputerFacade{
-		cpu:       &CPU{},
-		memory:    &Memory{},
-		hardDrive: &HardDrive{},
+		cpu:       cpu,
+		memory:    memory,
+		hardDrive: hardDrive,
+		timeline:  timeline,
+		boot:      boot,
+	}
+}
+
+func ignoreContext(fn func() error) func(context.Context) error {
+	return func(context.Context) error {
+		return fn()
+	}
+}
+
+// Timeline 返回外观的事件记录
+func (c *ComputerFacade) Timeline() *Timeline {
+	return c.timeline
+}
+
+// Start 提供了一个简单的接口来启动计算机并运行程序直到停机，某一步失败时按相反顺序关闭已经启动的子系统
+func (c *ComputerFacade) Start() error {
+	return c.StartContext(context.Background())
+}
+
+// StartContext 与 Start 相同。每个子系统的启动受组件超时限制，程序本身只受 ctx 限制，
+// 程序出错或 ctx 结束时关闭所有子系统并返回该错误。
+func (c *ComputerFacade) StartContext(ctx context.Context) error {
+	err := c.boot.Start(ctx)
+	if errors.Is(err, ErrLifecycleStarted) {
+		return fmt.Errorf("%w: %w", ErrAlreadyRunning, err)
+	}
+	if err != nil {
+		return err
 	}
+	if err := c.cpu.Run(ctx); err != nil {
+		// 程序运行期间已经被 Shutdown 时不再重复关闭
+		stopErr := c.boot.Stop(context.WithoutCancel(ctx))
+		if errors.Is(stopErr, ErrBootNotStarted) {
+			stopErr = nil
+		}
+		return errors.Join(err, stopErr)
+	}
+	return nil
 }
 
-// Start 提供了一个简单的接口来启动计算机
-func (c *ComputerFacade) Start() {
-	c.cpu.Start()
-	c.memory.Load()
-	c.hardDrive.Read()
-	c.cpu.Execute()
+// Shutdown 提供了一个简单的接口来关闭计算机，按启动的相反顺序关闭子系统
+func (c *ComputerFacade) Shutdown() error {
+	return c.ShutdownContext(context.Background())
 }
 
-// Shutdown 提供了一个简单的接口来关闭计算机
-func (c *ComputerFacade) Shutdown() {
-	c.cpu.Shutdown()
-	c.memory.Unload()
+func (c *ComputerFacade) ShutdownContext(ctx context.Context) error {
+	err := c.boot.Stop(ctx)
+	if errors.Is(err, ErrBootNotStarted) {
+		return fmt.Errorf("%w: %w", ErrComputerNotReady, err)
+	}
+	return err
 }
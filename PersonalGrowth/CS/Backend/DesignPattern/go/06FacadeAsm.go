@@ -0,0 +1,377 @@
+package designpattern
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 汇编器
+// 每行是 [标签:] [指令或伪指令] [; 注释]，指令的写法见 06FacadeVM.go。
+// 立即数可以是十进制、0x 十六进制、字符（'a'）或标签，标签的值是它的地址。
+// 伪指令：
+//
+//	.word v, ...        每个值占 4 个字节
+//	.byte v, ...        每个值占 1 个字节
+//	.string "text"      Go 语法的字符串，末尾补 0
+//
+// 例如：
+//
+//	        loadi r0, msg
+//	        sys 2
+//	        halt
+//	msg:    .string "hello, world"
+
+var (
+	ErrUnknownMnemonic = errors.New("unknown mnemonic")
+	ErrBadOperand      = errors.New("bad operand")
+	ErrUndefinedLabel  = errors.New("undefined label")
+	ErrDuplicateLabel  = errors.New("duplicate label")
+)
+
+// AssemblyError 汇编错误，Line 从 1 开始
+type AssemblyError struct {
+	Line int
+	Err  error
+}
+
+func (e *AssemblyError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *AssemblyError) Unwrap() error {
+	return e.Err
+}
+
+// asmStatement 一条指令或伪指令
+type asmStatement struct {
+	line     int
+	mnemonic string
+	operands []string
+	size     int
+}
+
+// Assemble 把汇编源码汇编为从地址 0 开始的程序镜像
+func Assemble(src string) ([]byte, error) {
+	labels := make(map[string]int)
+	var statements []*asmStatement
+	addr := 0
+
+	// 第一遍：确定每条语句的地址和标签
+	for i, text := range strings.Split(src, "\n") {
+		line := i + 1
+		fail := func(err error) error {
+			return &AssemblyError{Line: line, Err: err}
+		}
+		text = strings.TrimSpace(stripComment(text))
+		if label, rest, ok := cutLabel(text); ok {
+			if !isIdentifier(label) {
+				return nil, fail(fmt.Errorf("%w: invalid label %q", ErrBadOperand, label))
+			}
+			if _, ok := labels[label]; ok {
+				return nil, fail(fmt.Errorf("%w: %s", ErrDuplicateLabel, label))
+			}
+			labels[label] = addr
+			text = strings.TrimSpace(rest)
+		}
+		if text == "" {
+			continue
+		}
+		mnemonic, rest := text, ""
+		if i := strings.IndexFunc(text, unicode.IsSpace); i >= 0 {
+			mnemonic, rest = text[:i], text[i:]
+		}
+		stmt := &asmStatement{line: line, mnemonic: strings.ToLower(mnemonic)}
+		rest = strings.TrimSpace(rest)
+		switch stmt.mnemonic {
+		case ".string":
+			s, err := strconv.Unquote(rest)
+			if err != nil {
+				return nil, fail(fmt.Errorf("%w: .string %s", ErrBadOperand, rest))
+			}
+			stmt.operands = []string{s}
+			stmt.size = len(s) + 1
+		case ".word", ".byte":
+			stmt.operands = splitOperands(rest)
+			if len(stmt.operands) == 0 {
+				return nil, fail(fmt.Errorf("%w: %s needs at least one value", ErrBadOperand, stmt.mnemonic))
+			}
+			width := 4
+			if stmt.mnemonic == ".byte" {
+				width = 1
+			}
+			stmt.size = width * len(stmt.operands)
+		default:
+			if !isMnemonic(stmt.mnemonic) {
+				return nil, fail(fmt.Errorf("%w: %s", ErrUnknownMnemonic, mnemonic))
+			}
+			stmt.operands = splitOperands(rest)
+			stmt.size = InstructionSize
+		}
+		statements = append(statements, stmt)
+		addr += stmt.size
+		if addr > MemorySize {
+			return nil, fail(fmt.Errorf("%w: program exceeds %d bytes", ErrImageTooLarge, MemorySize))
+		}
+	}
+
+	// 第二遍：编码
+	image := make([]byte, 0, addr)
+	for _, stmt := range statements {
+		b, err := stmt.encode(labels)
+		if err != nil {
+			return nil, &AssemblyError{Line: stmt.line, Err: err}
+		}
+		image = append(image, b...)
+	}
+	return image, nil
+}
+
+func (s *asmStatement) encode(labels map[string]int) ([]byte, error) {
+	switch s.mnemonic {
+	case ".string":
+		return append([]byte(s.operands[0]), 0), nil
+	case ".word", ".byte":
+		var b []byte
+		for _, operand := range s.operands {
+			if s.mnemonic == ".byte" {
+				v, err := asmValue(operand, labels, math.MinInt8, math.MaxUint8)
+				if err != nil {
+					return nil, err
+				}
+				b = append(b, byte(v))
+				continue
+			}
+			v, err := asmValue(operand, labels, math.MinInt32, math.MaxInt32)
+			if err != nil {
+				return nil, err
+			}
+			b = binary.LittleEndian.AppendUint32(b, uint32(v))
+		}
+		return b, nil
+	}
+
+	inst, err := s.instruction(labels)
+	if err != nil {
+		return nil, err
+	}
+	b := inst.Encode()
+	return b[:], nil
+}
+
+// instruction 按操作数的形式选择操作码
+func (s *asmStatement) instruction(labels map[string]int) (Instruction, error) {
+	var lastErr error
+	for op, info := range opcodes {
+		if info.name != s.mnemonic || !operandsMatch(info.format, s.operands) {
+			continue
+		}
+		inst := Instruction{Op: op}
+		operands := s.operands
+		switch info.format {
+		case formatR, formatRR, formatRRR:
+			regs := []*uint8{&inst.A, &inst.B, &inst.C}
+			for i, operand := range operands {
+				*regs[i], _ = parseRegister(operand)
+			}
+		case formatRM:
+			inst.A, _ = parseRegister(operands[0])
+			inst.B, _ = parseRegister(strings.TrimSpace(operands[1][1 : len(operands[1])-1]))
+		case formatRI, formatI:
+			if info.format == formatRI {
+				inst.A, _ = parseRegister(operands[0])
+				operands = operands[1:]
+			}
+			v, err := asmValue(operands[0], labels, math.MinInt16, math.MaxInt16)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			inst.Imm = int16(v)
+		}
+		return inst, nil
+	}
+	if lastErr != nil {
+		return Instruction{}, lastErr
+	}
+	return Instruction{}, fmt.Errorf("%w: %s %s", ErrBadOperand, s.mnemonic, strings.Join(s.operands, ", "))
+}
+
+func operandsMatch(format operandFormat, operands []string) bool {
+	isReg := func(s string) bool {
+		_, ok := parseRegister(s)
+		return ok
+	}
+	isMem := func(s string) bool {
+		return len(s) > 2 && s[0] == '[' && s[len(s)-1] == ']' && isReg(strings.TrimSpace(s[1:len(s)-1]))
+	}
+	isValue := func(s string) bool {
+		return !isReg(s) && !strings.HasPrefix(s, "[")
+	}
+	var want []func(string) bool
+	switch format {
+	case formatR:
+		want = []func(string) bool{isReg}
+	case formatRR:
+		want = []func(string) bool{isReg, isReg}
+	case formatRM:
+		want = []func(string) bool{isReg, isMem}
+	case formatRRR:
+		want = []func(string) bool{isReg, isReg, isReg}
+	case formatRI:
+		want = []func(string) bool{isReg, isValue}
+	case formatI:
+		want = []func(string) bool{isValue}
+	}
+	if len(operands) != len(want) {
+		return false
+	}
+	for i, match := range want {
+		if !match(operands[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func parseRegister(s string) (uint8, bool) {
+	s = strings.ToLower(s)
+	if s == "sp" {
+		return RegSP, true
+	}
+	if len(s) == 2 && s[0] == 'r' && s[1] >= '0' && s[1] < '0'+NumRegisters {
+		return s[1] - '0', true
+	}
+	return 0, false
+}
+
+// asmValue 解析数字、字符或标签，结果必须在 [lo, hi] 内
+func asmValue(s string, labels map[string]int, lo, hi int64) (int64, error) {
+	var v int64
+	switch {
+	case isIdentifier(s):
+		addr, ok := labels[s]
+		if !ok {
+			return 0, fmt.Errorf("%w: %s", ErrUndefinedLabel, s)
+		}
+		v = int64(addr)
+	case strings.HasPrefix(s, "'"):
+		r, _, tail, err := strconv.UnquoteChar(strings.TrimSuffix(s[1:], "'"), '\'')
+		if err != nil || tail != "" || !strings.HasSuffix(s, "'") {
+			return 0, fmt.Errorf("%w: %s", ErrBadOperand, s)
+		}
+		v = int64(r)
+	default:
+		n, err := strconv.ParseInt(s, 0, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %s", ErrBadOperand, s)
+		}
+		v = n
+	}
+	if v < lo || v > hi {
+		return 0, fmt.Errorf("%w: %s out of range [%d, %d]", ErrBadOperand, s, lo, hi)
+	}
+	return v, nil
+}
+
+func isMnemonic(name string) bool {
+	for _, info := range opcodes {
+		if info.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	_, isReg := parseRegister(s)
+	return !isReg
+}
+
+// stripComment 去掉 ; 之后的注释，忽略字符串和字符中的 ;
+func stripComment(s string) string {
+	if i := indexUnquoted(s, ';'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// indexUnquoted 返回 sep 在字符串和字符字面量之外第一次出现的位置，没有时返回 -1
+func indexUnquoted(s string, sep rune) int {
+	var quote rune
+	escaped := false
+	for i, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0 && r == '\\':
+			escaped = true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		case quote == 0 && r == sep:
+			return i
+		}
+	}
+	return -1
+}
+
+// cutLabel 拆出行首的 "label:"
+func cutLabel(s string) (label, rest string, ok bool) {
+	i := strings.IndexByte(s, ':')
+	if i < 0 || strings.ContainsAny(s[:i], " \t\"'") {
+		return "", s, false
+	}
+	return s[:i], s[i+1:], true
+}
+
+// splitOperands 按逗号拆分操作数，忽略字符串和字符中的逗号
+func splitOperands(s string) []string {
+	if s == "" {
+		return nil
+	}
+	var parts []string
+	for {
+		i := indexUnquoted(s, ',')
+		if i < 0 {
+			return append(parts, strings.TrimSpace(s))
+		}
+		parts = append(parts, strings.TrimSpace(s[:i]))
+		s = s[i+1:]
+	}
+}
+
+// Disassemble 把镜像反汇编为每 4 个字节一行的文本，不能解码的部分按 .word 输出
+func Disassemble(image []byte) []string {
+	var lines []string
+	for addr := 0; addr < len(image); addr += InstructionSize {
+		chunk := image[addr:min(addr+InstructionSize, len(image))]
+		text := ""
+		if inst, err := DecodeInstruction(chunk); err == nil {
+			text = inst.String()
+		} else if len(chunk) == InstructionSize {
+			text = fmt.Sprintf(".word %#08x", binary.LittleEndian.Uint32(chunk))
+		} else {
+			bytes := make([]string, len(chunk))
+			for i, b := range chunk {
+				bytes[i] = fmt.Sprintf("%#02x", b)
+			}
+			text = ".byte " + strings.Join(bytes, ", ")
+		}
+		lines = append(lines, fmt.Sprintf("%#04x  %s", addr, text))
+	}
+	return lines
+}
@@ -0,0 +1,120 @@
+package designpattern
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAssembleEncoding(t *testing.T) {
+	image, err := Assemble(`
+start:  loadi r1, -2        ; 负数立即数
+        load r2, [sp]
+        store r2, value
+        add r0, r1, r2
+        jnz r0, start
+        sys 1
+value:  .word 0x01020304, start
+        .byte 'A', 255
+        .string "a;b"
+        loadi r0, ','
+        .byte ',', 0
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		byte(OpLoadI), 1, 0xfe, 0xff,
+		byte(OpLoadR), 2, RegSP, 0,
+		byte(OpStore), 2, 24, 0,
+		byte(OpAdd), 0, 1, 2,
+		byte(OpJnz), 0, 0, 0,
+		byte(OpSys), 0, 1, 0,
+		4, 3, 2, 1, 0, 0, 0, 0,
+		'A', 255,
+		'a', ';', 'b', 0,
+		byte(OpLoadI), 0, ',', 0,
+		',', 0,
+	}
+	if !bytes.Equal(image, want) {
+		t.Fatalf("image = % x\nwant    % x", image, want)
+	}
+
+	inst, err := DecodeInstruction(image[8:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inst != (Instruction{Op: OpStore, A: 2, Imm: 24}) || inst.String() != "store r2, 24" {
+		t.Fatalf("decoded %+v (%s)", inst, inst)
+	}
+}
+
+func TestAssembleErrors(t *testing.T) {
+	cases := []struct {
+		src  string
+		line int
+		err  error
+	}{
+		{"nop\nfrob r1", 2, ErrUnknownMnemonic},
+		{"jmp nowhere", 1, ErrUndefinedLabel},
+		{"a: nop\na: nop", 2, ErrDuplicateLabel},
+		{"add r1, r2", 1, ErrBadOperand},
+		{"load r1, [r9]", 1, ErrBadOperand},
+		{"loadi r1, 40000", 1, ErrBadOperand},
+		{".byte 256", 1, ErrBadOperand},
+		{".string hello", 1, ErrBadOperand},
+		{"r1: nop", 1, ErrBadOperand},
+		{"big: .string \"" + string(make([]byte, MemorySize)) + "\"", 1, ErrImageTooLarge},
+	}
+	for _, tc := range cases {
+		_, err := Assemble(tc.src)
+		var asmErr *AssemblyError
+		if !errors.As(err, &asmErr) || asmErr.Line != tc.line || !errors.Is(err, tc.err) {
+			t.Errorf("Assemble(%.20q) err = %v, want line %d: %v", tc.src, err, tc.line, tc.err)
+		}
+	}
+}
+
+func TestDisassemble(t *testing.T) {
+	image, err := Assemble(factorialProgram)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := Disassemble(image)
+	want := []string{
+		"0x0000  loadi r0, 10",
+		"0x0004  call 16",
+		"0x0008  sys 1",
+		"0x000c  halt",
+		"0x0010  jz r0, 44",
+		"0x0014  push r0",
+		"0x0018  addi r0, -1",
+		"0x001c  call 16",
+		"0x0020  pop r1",
+		"0x0024  mul r0, r0, r1",
+		"0x0028  ret",
+		"0x002c  loadi r0, 1",
+		"0x0030  ret",
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Fatalf("Disassemble =\n%q\nwant\n%q", lines, want)
+	}
+
+	// 反汇编的结果可以重新汇编
+	var src bytes.Buffer
+	for _, line := range lines {
+		src.WriteString(line[8:] + "\n")
+	}
+	again, err := Assemble(src.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, image) {
+		t.Fatal("reassembled image differs")
+	}
+
+	if got := Disassemble([]byte{0xff, 0, 0, 0, 1, 2}); !reflect.DeepEqual(got, []string{"0x0000  .word 0x000000ff", "0x0004  .byte 0x01, 0x02"}) {
+		t.Fatalf("Disassemble(data) = %q", got)
+	}
+}
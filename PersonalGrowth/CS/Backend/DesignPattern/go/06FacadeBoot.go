@@ -0,0 +1,198 @@
+package designpattern
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// 启动编排
+// BootOrchestrator 按声明的依赖启动一组组件，互不依赖的组件并行启动，
+// 每个组件有自己的超时。某个组件启动失败时取消其余的启动，
+// 然后按完成的相反顺序停止已经启动的组件。
+// ComputerFacade 用它启动子系统，服务中可以用同样的方式启动数据库、缓存和消息队列的客户端。
+
+var ErrBootNotStarted = errors.New("boot not started")
+
+// BootOrchestrator 可并发使用，组件使用 LifecycleManager 的 Component 和 ComponentOption
+type BootOrchestrator struct {
+	mu         sync.Mutex // 保护 steps、registered 和 running
+	steps      map[string]*managedComponent
+	registered []string
+	running    bool
+
+	bootMu  sync.Mutex // 串行化 Start 和 Stop，保护 started
+	started []*managedComponent
+}
+
+func NewBootOrchestrator() *BootOrchestrator {
+	return &BootOrchestrator{steps: make(map[string]*managedComponent)}
+}
+
+// Add 添加组件，启动后不能再添加
+func (o *BootOrchestrator) Add(name string, component Component, opts ...ComponentOption) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.running {
+		return ErrLifecycleStarted
+	}
+	if _, ok := o.steps[name]; ok {
+		return fmt.Errorf("%w: %s", ErrComponentExists, name)
+	}
+	c := &managedComponent{
+		name:         name,
+		component:    component,
+		startTimeout: DefaultComponentTimeout,
+		stopTimeout:  DefaultComponentTimeout,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	o.steps[name] = c
+	o.registered = append(o.registered, name)
+	return nil
+}
+
+// Order 返回一个满足依赖的顺序，并行启动时实际的完成顺序可能不同
+func (o *BootOrchestrator) Order() ([]string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.order()
+}
+
+func (o *BootOrchestrator) order() ([]string, error) {
+	deps := make(map[string][]string, len(o.steps))
+	for name, c := range o.steps {
+		deps[name] = c.dependsOn
+	}
+	return dependencyOrder(o.registered, deps)
+}
+
+// Running 返回是否已经启动
+func (o *BootOrchestrator) Running() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.running
+}
+
+// Started 返回已经启动的组件，按完成顺序
+func (o *BootOrchestrator) Started() []string {
+	o.bootMu.Lock()
+	defer o.bootMu.Unlock()
+	names := make([]string, len(o.started))
+	for i, c := range o.started {
+		names[i] = c.name
+	}
+	return names
+}
+
+// bootResult 一个组件的启动结果，done 关闭后 ok 才可以读取
+type bootResult struct {
+	done chan struct{}
+	ok   bool
+}
+
+// Start 启动所有组件，每个组件在其依赖都启动后立即启动
+// 失败时返回所有组件的错误（因取消而失败的除外）以及回滚时停止组件的错误
+func (o *BootOrchestrator) Start(ctx context.Context) error {
+	o.bootMu.Lock()
+	defer o.bootMu.Unlock()
+
+	o.mu.Lock()
+	if o.running {
+		o.mu.Unlock()
+		return ErrLifecycleStarted
+	}
+	order, err := o.order()
+	if err != nil {
+		o.mu.Unlock()
+		return err
+	}
+	steps := make([]*managedComponent, len(order))
+	for i, name := range order {
+		steps[i] = o.steps[name]
+	}
+	o.running = true
+	o.mu.Unlock()
+
+	bootCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(map[string]*bootResult, len(steps))
+	for _, c := range steps {
+		results[c.name] = &bootResult{done: make(chan struct{})}
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex // 保护 errs、aborted 和 o.started
+		errs    []error
+		aborted bool
+	)
+	for _, c := range steps {
+		wg.Add(1)
+		go func(c *managedComponent) {
+			defer wg.Done()
+			result := results[c.name]
+			defer close(result.done)
+			for _, dep := range c.dependsOn {
+				<-results[dep].done
+				if !results[dep].ok {
+					return
+				}
+			}
+			if bootCtx.Err() != nil {
+				return
+			}
+			err := callWithTimeout(bootCtx, c.startTimeout, c.component.Start)
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				result.ok = true
+				o.started = append(o.started, c)
+				return
+			}
+			if !aborted || !errors.Is(err, context.Canceled) {
+				errs = append(errs, fmt.Errorf("start %s: %w", c.name, err))
+			}
+			aborted = true
+			cancel()
+		}(c)
+	}
+	wg.Wait()
+
+	if len(o.started) == len(steps) {
+		return nil
+	}
+	if len(errs) == 0 {
+		errs = append(errs, ctx.Err())
+	}
+	errs = append(errs, o.stop(context.WithoutCancel(ctx)))
+	return errors.Join(errs...)
+}
+
+// Stop 按启动完成的相反顺序停止组件，某个组件停止失败不影响其它组件，返回所有错误
+func (o *BootOrchestrator) Stop(ctx context.Context) error {
+	o.bootMu.Lock()
+	defer o.bootMu.Unlock()
+	if !o.Running() {
+		return ErrBootNotStarted
+	}
+	return o.stop(ctx)
+}
+
+func (o *BootOrchestrator) stop(ctx context.Context) error {
+	var errs []error
+	for i := len(o.started) - 1; i >= 0; i-- {
+		c := o.started[i]
+		if err := callWithTimeout(ctx, c.stopTimeout, c.component.Stop); err != nil {
+			errs = append(errs, fmt.Errorf("stop %s: %w", c.name, err))
+		}
+	}
+	o.started = nil
+
+	o.mu.Lock()
+	o.running = false
+	o.mu.Unlock()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,176 @@
+package designpattern
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBootOrchestratorOrder(t *testing.T) {
+	var log componentLog
+	o := NewBootOrchestrator()
+	o.Add("api", log.component("api"), WithDependsOn("cache", "db"))
+	o.Add("cache", log.component("cache"), WithDependsOn("config"))
+	o.Add("db", log.component("db"), WithDependsOn("config"))
+	o.Add("config", log.component("config"))
+
+	if err := o.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !o.Running() {
+		t.Fatal("Running() = false after Start")
+	}
+	if err := o.Start(context.Background()); !errors.Is(err, ErrLifecycleStarted) {
+		t.Fatalf("second Start err = %v, want ErrLifecycleStarted", err)
+	}
+	if err := o.Add("late", log.component("late")); !errors.Is(err, ErrLifecycleStarted) {
+		t.Fatalf("Add after Start err = %v, want ErrLifecycleStarted", err)
+	}
+
+	started := o.Started()
+	if err := o.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Stop(context.Background()); !errors.Is(err, ErrBootNotStarted) {
+		t.Fatalf("second Stop err = %v, want ErrBootNotStarted", err)
+	}
+
+	// cache 和 db 的顺序不固定，停止的顺序是启动完成顺序的反序
+	assertGroups(t, log.events[:4],
+		[]string{"start config"},
+		[]string{"start cache", "start db"},
+		[]string{"start api"},
+	)
+	var want []string
+	for i := len(started) - 1; i >= 0; i-- {
+		want = append(want, "stop "+started[i])
+	}
+	if got := log.events[4:]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("stop order = %v, want %v", got, want)
+	}
+}
+
+func TestBootOrchestratorParallel(t *testing.T) {
+	// a 和 b 都要等到对方开始启动才能完成，只有并行启动才能成功
+	aStarted, bStarted := make(chan struct{}), make(chan struct{})
+	wait := func(self, other chan struct{}) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			close(self)
+			select {
+			case <-other:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	o := NewBootOrchestrator()
+	o.Add("a", ComponentHooks{OnStart: wait(aStarted, bStarted)}, WithStartTimeout(time.Second))
+	o.Add("b", ComponentHooks{OnStart: wait(bStarted, aStarted)}, WithStartTimeout(time.Second))
+	if err := o.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	o.Stop(context.Background())
+}
+
+func TestBootOrchestratorRollback(t *testing.T) {
+	var log componentLog
+	startErr := errors.New("connection refused")
+	cache := log.component("cache")
+	cache.startErr = startErr
+
+	o := NewBootOrchestrator()
+	o.Add("config", log.component("config"))
+	o.Add("db", log.component("db"), WithDependsOn("config"))
+	o.Add("cache", cache, WithDependsOn("config"))
+	o.Add("queue", ComponentHooks{OnStart: func(ctx context.Context) error {
+		// 启动较慢的组件在其它组件失败后被取消（或者还没有开始启动），它的错误不会返回
+		<-ctx.Done()
+		return ctx.Err()
+	}}, WithDependsOn("config"))
+	o.Add("api", log.component("api"), WithDependsOn("db", "cache", "queue"))
+
+	err := o.Start(context.Background())
+	if !errors.Is(err, startErr) {
+		t.Fatalf("Start err = %v, want %v", err, startErr)
+	}
+	if errors.Is(err, context.Canceled) {
+		t.Fatalf("Start err = %v, should not report the canceled queue", err)
+	}
+	if o.Running() || len(o.Started()) != 0 {
+		t.Fatalf("orchestrator still running after a failed Start: %v", o.Started())
+	}
+
+	// db 可能在 cache 失败之前或之后完成，完成了就会被停止
+	for _, event := range log.events {
+		if event == "start api" {
+			t.Fatal("api started although cache failed")
+		}
+	}
+	last := log.events[len(log.events)-1]
+	if last != "stop config" {
+		t.Fatalf("events = %v, want config stopped last", log.events)
+	}
+}
+
+func TestBootOrchestratorTimeout(t *testing.T) {
+	var log componentLog
+	block := make(chan struct{})
+	defer close(block)
+
+	o := NewBootOrchestrator()
+	o.Add("config", log.component("config"))
+	o.Add("db", ComponentHooks{OnStart: func(context.Context) error {
+		<-block // 忽略 ctx
+		return nil
+	}}, WithDependsOn("config"), WithStartTimeout(20*time.Millisecond))
+
+	err := o.Start(context.Background())
+	if !errors.Is(err, ErrComponentTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Start err = %v, want ErrComponentTimeout", err)
+	}
+	if want := []string{"start config", "stop config"}; !reflect.DeepEqual(log.events, want) {
+		t.Fatalf("events = %v, want %v", log.events, want)
+	}
+}
+
+func TestBootOrchestratorCanceled(t *testing.T) {
+	var log componentLog
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	o := NewBootOrchestrator()
+	o.Add("config", log.component("config"))
+	if err := o.Start(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Start err = %v, want context.Canceled", err)
+	}
+	if len(log.events) != 0 {
+		t.Fatalf("events = %v, want nothing started", log.events)
+	}
+}
+
+func TestBootOrchestratorInvalid(t *testing.T) {
+	var log componentLog
+	o := NewBootOrchestrator()
+	o.Add("a", log.component("a"), WithDependsOn("b"))
+	o.Add("b", log.component("b"), WithDependsOn("a"))
+	if err := o.Add("a", log.component("a")); !errors.Is(err, ErrComponentExists) {
+		t.Fatalf("duplicate Add err = %v, want ErrComponentExists", err)
+	}
+	if err := o.Start(context.Background()); !errors.Is(err, ErrDependencyCycle) {
+		t.Fatalf("Start err = %v, want ErrDependencyCycle", err)
+	}
+	if o.Running() {
+		t.Fatal("Running() = true after an invalid Start")
+	}
+
+	o = NewBootOrchestrator()
+	o.Add("a", log.component("a"), WithDependsOn("missing"))
+	if _, err := o.Order(); !errors.Is(err, ErrMissingDependency) {
+		t.Fatalf("Order err = %v, want ErrMissingDependency", err)
+	}
+	if len(log.events) != 0 {
+		t.Fatalf("events = %v, want nothing started", log.events)
+	}
+}
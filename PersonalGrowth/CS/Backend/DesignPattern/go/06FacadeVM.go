@@ -0,0 +1,598 @@
+package designpattern
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// 指令集
+// 每条指令固定 4 个字节：操作码、寄存器 a、寄存器 b、寄存器 c。带立即数的指令把
+// 16 位有符号立即数（小端）放在后两个字节。CPU 有 8 个 32 位寄存器 r0-r7，
+// r7 也写作 sp，是栈指针，开始执行时指向内存末尾，栈向低地址增长。
+// 字是 4 个字节的小端有符号整数，地址不要求对齐。
+//
+//	halt                停机
+//	nop
+//	loadi  rd, imm      rd = imm
+//	mov    rd, rs       rd = rs
+//	load   rd, addr     rd = 地址 addr 处的字
+//	load   rd, [rs]     rd = 地址 rs 处的字
+//	store  rs, addr     把 rs 写到地址 addr
+//	store  rs, [rd]     把 rs 写到地址 rd
+//	loadb  rd, [rs]     rd = 地址 rs 处的字节（无符号）
+//	storeb rs, [rd]     把 rs 的低 8 位写到地址 rd
+//	add    rd, rs, rt   rd = rs + rt，sub、mul、div、mod 相同，除以 0 是错误
+//	addi   rd, imm      rd = rd + imm
+//	jmp    addr         跳转
+//	jz     rs, addr     rs == 0 时跳转
+//	jnz    rs, addr     rs != 0 时跳转
+//	jlt    rs, addr     rs < 0 时跳转
+//	call   addr         压入返回地址后跳转
+//	ret                 弹出返回地址并跳转
+//	push   rs           sp -= 4，把 rs 写到地址 sp
+//	pop    rd           rd = 地址 sp 处的字，sp += 4
+//	sys    n            系统调用，见 Syscall 常量
+//
+// 汇编器见 06FacadeAsm.go。
+
+var (
+	ErrIllegalInstruction = errors.New("illegal instruction")
+	ErrMemoryFault        = errors.New("memory fault")
+	ErrDivideByZero       = errors.New("divide by zero")
+	ErrUnknownSyscall     = errors.New("unknown syscall")
+	ErrNotExecuting       = errors.New("cpu not executing")
+)
+
+const (
+	InstructionSize = 4
+	NumRegisters    = 8
+	RegSP           = 7
+)
+
+// 系统调用，输出通过 emit 发出，Source 为 "Program"
+const (
+	SyscallPrintInt    = 1 // 输出 r0 的十进制值
+	SyscallPrintString = 2 // 输出 r0 指向的以 0 结尾的字符串
+)
+
+// Opcode 操作码
+type Opcode uint8
+
+const (
+	OpHalt   Opcode = 0x00
+	OpNop    Opcode = 0x01
+	OpLoadI  Opcode = 0x02
+	OpMov    Opcode = 0x03
+	OpLoad   Opcode = 0x04
+	OpStore  Opcode = 0x05
+	OpLoadR  Opcode = 0x06
+	OpStoreR Opcode = 0x07
+	OpLoadB  Opcode = 0x08
+	OpStoreB Opcode = 0x09
+	OpAdd    Opcode = 0x10
+	OpSub    Opcode = 0x11
+	OpMul    Opcode = 0x12
+	OpDiv    Opcode = 0x13
+	OpMod    Opcode = 0x14
+	OpAddI   Opcode = 0x15
+	OpJmp    Opcode = 0x20
+	OpJz     Opcode = 0x21
+	OpJnz    Opcode = 0x22
+	OpJlt    Opcode = 0x23
+	OpCall   Opcode = 0x24
+	OpRet    Opcode = 0x25
+	OpPush   Opcode = 0x26
+	OpPop    Opcode = 0x27
+	OpSys    Opcode = 0x30
+)
+
+// operandFormat 指令的操作数形式
+type operandFormat int
+
+const (
+	formatNone operandFormat = iota // halt
+	formatR                         // push r1
+	formatRR                        // mov r1, r2
+	formatRM                        // load r1, [r2]
+	formatRRR                       // add r1, r2, r3
+	formatRI                        // loadi r1, 5
+	formatI                         // jmp label
+)
+
+type opcodeInfo struct {
+	name   string
+	format operandFormat
+}
+
+// opcodes 同一个助记符可以对应多个操作码，汇编器按操作数形式选择
+var opcodes = map[Opcode]opcodeInfo{
+	OpHalt:   {"halt", formatNone},
+	OpNop:    {"nop", formatNone},
+	OpLoadI:  {"loadi", formatRI},
+	OpMov:    {"mov", formatRR},
+	OpLoad:   {"load", formatRI},
+	OpStore:  {"store", formatRI},
+	OpLoadR:  {"load", formatRM},
+	OpStoreR: {"store", formatRM},
+	OpLoadB:  {"loadb", formatRM},
+	OpStoreB: {"storeb", formatRM},
+	OpAdd:    {"add", formatRRR},
+	OpSub:    {"sub", formatRRR},
+	OpMul:    {"mul", formatRRR},
+	OpDiv:    {"div", formatRRR},
+	OpMod:    {"mod", formatRRR},
+	OpAddI:   {"addi", formatRI},
+	OpJmp:    {"jmp", formatI},
+	OpJz:     {"jz", formatRI},
+	OpJnz:    {"jnz", formatRI},
+	OpJlt:    {"jlt", formatRI},
+	OpCall:   {"call", formatI},
+	OpRet:    {"ret", formatNone},
+	OpPush:   {"push", formatR},
+	OpPop:    {"pop", formatR},
+	OpSys:    {"sys", formatI},
+}
+
+// Instruction 解码后的指令，A、B、C 是寄存器编号
+type Instruction struct {
+	Op      Opcode
+	A, B, C uint8
+	Imm     int16
+}
+
+func (i Instruction) usesImm() bool {
+	format := opcodes[i.Op].format
+	return format == formatRI || format == formatI
+}
+
+// Encode 编码为 4 个字节
+func (i Instruction) Encode() [InstructionSize]byte {
+	b := [InstructionSize]byte{byte(i.Op), i.A, i.B, i.C}
+	if i.usesImm() {
+		binary.LittleEndian.PutUint16(b[2:], uint16(i.Imm))
+	}
+	return b
+}
+
+// DecodeInstruction 解码 b 开头的 4 个字节
+func DecodeInstruction(b []byte) (Instruction, error) {
+	if len(b) < InstructionSize {
+		return Instruction{}, fmt.Errorf("%w: truncated", ErrIllegalInstruction)
+	}
+	i := Instruction{Op: Opcode(b[0]), A: b[1], B: b[2], C: b[3]}
+	info, ok := opcodes[i.Op]
+	if !ok {
+		return Instruction{}, fmt.Errorf("%w: opcode %#02x", ErrIllegalInstruction, b[0])
+	}
+	if i.usesImm() {
+		i.B, i.C = 0, 0
+		i.Imm = int16(binary.LittleEndian.Uint16(b[2:]))
+	}
+	var regs []uint8
+	switch info.format {
+	case formatR, formatRI:
+		regs = []uint8{i.A}
+	case formatRR, formatRM:
+		regs = []uint8{i.A, i.B}
+	case formatRRR:
+		regs = []uint8{i.A, i.B, i.C}
+	}
+	for _, r := range regs {
+		if r >= NumRegisters {
+			return Instruction{}, fmt.Errorf("%w: register %d", ErrIllegalInstruction, r)
+		}
+	}
+	return i, nil
+}
+
+func registerName(r uint8) string {
+	if r == RegSP {
+		return "sp"
+	}
+	return fmt.Sprintf("r%d", r)
+}
+
+// String 返回汇编形式，例如 "load r1, [r2]"
+func (i Instruction) String() string {
+	info, ok := opcodes[i.Op]
+	if !ok {
+		return fmt.Sprintf("illegal %#02x", byte(i.Op))
+	}
+	var operands []string
+	switch info.format {
+	case formatR:
+		operands = []string{registerName(i.A)}
+	case formatRR:
+		operands = []string{registerName(i.A), registerName(i.B)}
+	case formatRM:
+		operands = []string{registerName(i.A), "[" + registerName(i.B) + "]"}
+	case formatRRR:
+		operands = []string{registerName(i.A), registerName(i.B), registerName(i.C)}
+	case formatRI:
+		operands = []string{registerName(i.A), fmt.Sprint(i.Imm)}
+	case formatI:
+		operands = []string{fmt.Sprint(i.Imm)}
+	}
+	if len(operands) == 0 {
+		return info.name
+	}
+	return info.name + " " + strings.Join(operands, ", ")
+}
+
+// CPUFault 执行指令时的错误，CPU 随即停机
+type CPUFault struct {
+	PC  int
+	Err error
+}
+
+func (f *CPUFault) Error() string {
+	return fmt.Sprintf("cpu fault at %#04x: %v", f.PC, f.Err)
+}
+
+func (f *CPUFault) Unwrap() error {
+	return f.Err
+}
+
+// Registers 通用寄存器
+type Registers [NumRegisters]int32
+
+func (r Registers) String() string {
+	parts := make([]string, NumRegisters)
+	for i, v := range r {
+		parts[i] = fmt.Sprintf("%s=%d", registerName(uint8(i)), v)
+	}
+	return strings.Join(parts, " ")
+}
+
+// TraceEntry 一条已执行的指令，Registers 为执行后的值
+type TraceEntry struct {
+	Step        int
+	PC          int
+	Instruction Instruction
+	Registers   Registers
+}
+
+func (e TraceEntry) String() string {
+	return fmt.Sprintf("%4d %#04x  %-18s %s", e.Step, e.PC, e.Instruction, e.Registers)
+}
+
+// TraceWriter 返回把每条指令写成一行的跟踪函数
+func TraceWriter(w io.Writer) func(TraceEntry) {
+	return func(e TraceEntry) {
+		fmt.Fprintln(w, e)
+	}
+}
+
+// SetTrace 设置每执行一条指令后调用的函数，nil 表示不跟踪
+func (c *CPU) SetTrace(fn func(TraceEntry)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.trace = fn
+}
+
+func (c *CPU) Registers() Registers {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.regs
+}
+
+// PC 返回下一条要执行的指令的地址，停机后为 halt 指令的地址
+func (c *CPU) PC() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pc
+}
+
+// Steps 返回本次执行已经执行的指令数
+func (c *CPU) Steps() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.steps
+}
+
+// Begin 从地址 0 开始执行 memory 中的程序，之后用 Step 逐条执行或用 Run 执行到停机
+// CPU 必须已经启动并且内存已经加载，停机后可以再次 Begin
+func (c *CPU) Begin(memory *Memory) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := c.transition("CPU", "execute", StateOff, StateExecuting, func(from SubsystemState) error {
+		switch {
+		case from == StateOff:
+			return ErrPoweredOff
+		case from == StateExecuting:
+			return ErrAlreadyRunning
+		case memory.State() != StateLoaded:
+			return ErrMemoryNotLoaded
+		}
+		return nil
+	})
+	if err == nil {
+		c.memory = memory
+		c.regs = Registers{}
+		c.regs[RegSP] = MemorySize
+		c.pc = 0
+		c.steps = 0
+		emit("CPU", "CPU is executing")
+	}
+	return err
+}
+
+// Execute 执行 memory 中的程序直到停机、出错或 ctx 结束
+func (c *CPU) Execute(ctx context.Context, memory *Memory) error {
+	if err := c.Begin(memory); err != nil {
+		return err
+	}
+	return c.Run(ctx)
+}
+
+// Run 执行到停机、出错或 ctx 结束，ctx 结束时 CPU 保持执行状态，可以继续执行
+func (c *CPU) Run(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		entry, err := c.Step()
+		if err != nil {
+			return err
+		}
+		if entry.Instruction.Op == OpHalt {
+			return nil
+		}
+	}
+}
+
+// Step 执行一条指令，出错时返回 *CPUFault 并停机
+func (c *CPU) Step() (TraceEntry, error) {
+	c.mu.Lock()
+	from := c.current(StateOff)
+	if from != StateExecuting {
+		err := &SubsystemError{Component: "CPU", Op: "step", State: from, Err: ErrNotExecuting}
+		c.timeline.record("CPU", "step", from, from, err)
+		c.mu.Unlock()
+		return TraceEntry{}, err
+	}
+
+	pc := c.pc
+	inst, err := c.fetch()
+	if err == nil {
+		err = c.exec(inst)
+	}
+	if err != nil {
+		err = &CPUFault{PC: pc, Err: err}
+		c.state = StateHalted
+		c.timeline.record("CPU", "fault", from, StateHalted, err)
+		c.mu.Unlock()
+		return TraceEntry{}, err
+	}
+	c.steps++
+	entry := TraceEntry{Step: c.steps, PC: pc, Instruction: inst, Registers: c.regs}
+	if inst.Op == OpHalt {
+		c.state = StateHalted
+		c.timeline.record("CPU", "halt", from, StateHalted, nil)
+	}
+	trace := c.trace
+	c.mu.Unlock()
+
+	if trace != nil {
+		trace(entry)
+	}
+	return entry, nil
+}
+
+func (c *CPU) fetch() (Instruction, error) {
+	b, err := c.memory.read(c.pc, InstructionSize)
+	if err != nil {
+		return Instruction{}, err
+	}
+	return DecodeInstruction(b)
+}
+
+// exec 执行一条已经解码的指令，在 c.mu 已加锁时调用
+func (c *CPU) exec(inst Instruction) error {
+	r := &c.regs
+	a, b, rc := inst.A, inst.B, inst.C
+	next := c.pc + InstructionSize
+	c.pc = next
+	jump := func(cond bool) {
+		if cond {
+			c.pc = int(inst.Imm)
+		}
+	}
+
+	switch inst.Op {
+	case OpHalt:
+		c.pc = next - InstructionSize
+	case OpNop:
+	case OpLoadI:
+		r[a] = int32(inst.Imm)
+	case OpMov:
+		r[a] = r[b]
+	case OpLoad, OpLoadR:
+		addr := int(inst.Imm)
+		if inst.Op == OpLoadR {
+			addr = int(r[b])
+		}
+		v, err := c.memory.PeekWord(addr)
+		if err != nil {
+			return err
+		}
+		r[a] = v
+	case OpStore, OpStoreR:
+		addr := int(inst.Imm)
+		if inst.Op == OpStoreR {
+			addr = int(r[b])
+		}
+		return c.memory.PokeWord(addr, r[a])
+	case OpLoadB:
+		v, err := c.memory.Peek(int(r[b]))
+		if err != nil {
+			return err
+		}
+		r[a] = int32(v)
+	case OpStoreB:
+		return c.memory.Poke(int(r[b]), byte(r[a]))
+	case OpAdd:
+		r[a] = r[b] + r[rc]
+	case OpSub:
+		r[a] = r[b] - r[rc]
+	case OpMul:
+		r[a] = r[b] * r[rc]
+	case OpDiv, OpMod:
+		if r[rc] == 0 {
+			return ErrDivideByZero
+		}
+		if inst.Op == OpDiv {
+			r[a] = r[b] / r[rc]
+		} else {
+			r[a] = r[b] % r[rc]
+		}
+	case OpAddI:
+		r[a] += int32(inst.Imm)
+	case OpJmp:
+		jump(true)
+	case OpJz:
+		jump(r[a] == 0)
+	case OpJnz:
+		jump(r[a] != 0)
+	case OpJlt:
+		jump(r[a] < 0)
+	case OpCall:
+		if err := c.push(int32(next)); err != nil {
+			return err
+		}
+		jump(true)
+	case OpRet:
+		v, err := c.pop()
+		if err != nil {
+			return err
+		}
+		c.pc = int(v)
+	case OpPush:
+		return c.push(r[a])
+	case OpPop:
+		v, err := c.pop()
+		if err != nil {
+			return err
+		}
+		r[a] = v
+	case OpSys:
+		return c.syscall(int(inst.Imm))
+	default:
+		return fmt.Errorf("%w: opcode %#02x", ErrIllegalInstruction, byte(inst.Op))
+	}
+	return nil
+}
+
+func (c *CPU) push(v int32) error {
+	sp := c.regs[RegSP] - 4
+	if err := c.memory.PokeWord(int(sp), v); err != nil {
+		return err
+	}
+	c.regs[RegSP] = sp
+	return nil
+}
+
+func (c *CPU) pop() (int32, error) {
+	sp := c.regs[RegSP]
+	v, err := c.memory.PeekWord(int(sp))
+	if err != nil {
+		return 0, err
+	}
+	c.regs[RegSP] = sp + 4
+	return v, nil
+}
+
+func (c *CPU) syscall(n int) error {
+	switch n {
+	case SyscallPrintInt:
+		emitf("Program", "%d", c.regs[0])
+	case SyscallPrintString:
+		var sb strings.Builder
+		for addr := int(c.regs[0]); ; addr++ {
+			b, err := c.memory.Peek(addr)
+			if err != nil {
+				return err
+			}
+			if b == 0 {
+				break
+			}
+			sb.WriteByte(b)
+		}
+		emit("Program", sb.String())
+	default:
+		return fmt.Errorf("%w: %d", ErrUnknownSyscall, n)
+	}
+	return nil
+}
+
+// access 返回 [addr, addr+n) 对应的字节，在 m.mu 已加锁时调用
+func (m *Memory) access(addr, n int) ([]byte, error) {
+	if m.data == nil {
+		return nil, ErrMemoryNotLoaded
+	}
+	if addr < 0 || addr+n > len(m.data) {
+		return nil, fmt.Errorf("%w: address %#04x", ErrMemoryFault, addr)
+	}
+	return m.data[addr : addr+n], nil
+}
+
+// read 返回 [addr, addr+n) 的副本
+func (m *Memory) read(addr, n int) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, err := m.access(addr, n)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), b...), nil
+}
+
+// Peek 读取一个字节
+func (m *Memory) Peek(addr int) (byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, err := m.access(addr, 1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// Poke 写入一个字节
+func (m *Memory) Poke(addr int, v byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, err := m.access(addr, 1)
+	if err != nil {
+		return err
+	}
+	b[0] = v
+	return nil
+}
+
+// PeekWord 读取一个字
+func (m *Memory) PeekWord(addr int) (int32, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, err := m.access(addr, 4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.LittleEndian.Uint32(b)), nil
+}
+
+// PokeWord 写入一个字
+func (m *Memory) PokeWord(addr int, v int32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, err := m.access(addr, 4)
+	if err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint32(b, uint32(v))
+	return nil
+}
@@ -0,0 +1,375 @@
+package designpattern
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 示例程序
+const (
+	helloProgram = `
+        loadi r0, msg
+        sys 2               ; 输出字符串
+        halt
+msg:    .string "hello, world"
+`
+
+	// 递归计算 10!，用栈保存参数
+	factorialProgram = `
+        loadi r0, 10
+        call fact
+        sys 1
+        halt
+
+fact:   jz r0, base         ; fact(0) = 1
+        push r0
+        addi r0, -1
+        call fact           ; r0 = fact(n-1)
+        pop r1
+        mul r0, r0, r1
+        ret
+base:   loadi r0, 1
+        ret
+`
+
+	fibonacciProgram = `
+        loadi r1, 0
+        loadi r2, 1
+        loadi r3, 10        ; 输出前 10 项
+loop:   mov r0, r1
+        sys 1
+        add r4, r1, r2
+        mov r1, r2
+        mov r2, r4
+        addi r3, -1
+        jnz r3, loop
+        halt
+`
+
+	// 对数组求和，结果写回内存后再读出来输出
+	sumProgram = `
+        loadi r1, data
+        load r2, count
+        loadi r0, 0
+loop:   jz r2, done
+        load r3, [r1]
+        add r0, r0, r3
+        addi r1, 4
+        addi r2, -1
+        jmp loop
+done:   store r0, total
+        loadi r0, 0
+        load r0, total
+        sys 1
+        halt
+count:  .word 5
+data:   .word 3, 1, 4, 1, 5
+total:  .word 0
+`
+
+	// 把字符串原地转为大写，用 loadb/storeb 逐字节处理
+	upperProgram = `
+        loadi r1, text
+loop:   loadb r2, [r1]
+        jz r2, done
+        loadi r3, 'a'
+        sub r3, r2, r3
+        jlt r3, next        ; r2 < 'a'
+        loadi r3, 'z'
+        sub r3, r3, r2
+        jlt r3, next        ; r2 > 'z'
+        addi r2, -32
+        storeb r2, [r1]
+next:   addi r1, 1
+        jmp loop
+done:   loadi r0, text
+        sys 2
+        halt
+text:   .string "Hello, VM; 42!"
+`
+)
+
+// programOutput 返回程序通过系统调用输出的内容
+func programOutput(recorder *EventRecorder) []string {
+	var output []string
+	for _, event := range recorder.Events() {
+		if event.Source == "Program" {
+			output = append(output, event.Message)
+		}
+	}
+	return output
+}
+
+// bootProgram 汇编 src，装入内存并启动 CPU，但还没有开始执行
+func bootProgram(t *testing.T, src string) (*CPU, *Memory) {
+	t.Helper()
+	image, err := Assemble(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpu, memory := &CPU{}, &Memory{}
+	if err := memory.Load(image); err != nil {
+		t.Fatal(err)
+	}
+	if err := cpu.Start(); err != nil {
+		t.Fatal(err)
+	}
+	return cpu, memory
+}
+
+func TestVMPrograms(t *testing.T) {
+	cases := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{"hello", helloProgram, []string{"hello, world"}},
+		{"factorial", factorialProgram, []string{"3628800"}},
+		{"fibonacci", fibonacciProgram, strings.Fields("0 1 1 2 3 5 8 13 21 34")},
+		{"sum", sumProgram, []string{"14"}},
+		{"upper", upperProgram, []string{"HELLO, VM; 42!"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := recordOutput(t)
+			cpu, memory := bootProgram(t, tc.src)
+			if err := cpu.Execute(context.Background(), memory); err != nil {
+				t.Fatal(err)
+			}
+			if got := programOutput(recorder); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("output = %q, want %q", got, tc.want)
+			}
+			if cpu.State() != StateHalted {
+				t.Fatalf("state = %s, want halted", cpu.State())
+			}
+			if sp := cpu.Registers()[RegSP]; sp != MemorySize {
+				t.Fatalf("sp = %d after the program, want %d", sp, MemorySize)
+			}
+		})
+	}
+}
+
+func TestVMStepAndTrace(t *testing.T) {
+	recordOutput(t)
+	cpu, memory := bootProgram(t, `
+        loadi r1, 6
+        loadi r2, 7
+        mul r0, r1, r2
+        halt
+`)
+	if _, err := cpu.Step(); !errors.Is(err, ErrNotExecuting) {
+		t.Fatalf("Step before Begin err = %v, want ErrNotExecuting", err)
+	}
+	var trace strings.Builder
+	cpu.SetTrace(TraceWriter(&trace))
+	if err := cpu.Begin(memory); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := cpu.Step()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Step != 1 || entry.PC != 0 || entry.Instruction.String() != "loadi r1, 6" || entry.Registers[1] != 6 {
+		t.Fatalf("first step = %+v", entry)
+	}
+	if cpu.PC() != 4 {
+		t.Fatalf("PC() = %d, want 4", cpu.PC())
+	}
+	cpu.Step()
+	entry, _ = cpu.Step()
+	if entry.Registers[0] != 42 {
+		t.Fatalf("r0 = %d, want 42", entry.Registers[0])
+	}
+	entry, _ = cpu.Step()
+	if entry.Instruction.Op != OpHalt || cpu.State() != StateHalted || cpu.PC() != 12 || cpu.Steps() != 4 {
+		t.Fatalf("after halt: %+v, state %s, pc %d", entry, cpu.State(), cpu.PC())
+	}
+	if _, err := cpu.Step(); !errors.Is(err, ErrNotExecuting) {
+		t.Fatalf("Step after halt err = %v, want ErrNotExecuting", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(trace.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("trace has %d lines:\n%s", len(lines), trace.String())
+	}
+	if want := "   3 0x0008  mul r0, r1, r2     r0=42 r1=6 r2=7 r3=0 r4=0 r5=0 r6=0 sp=32768"; lines[2] != want {
+		t.Fatalf("trace line = %q, want %q", lines[2], want)
+	}
+
+	// 停机后可以重新执行
+	if err := cpu.Execute(context.Background(), memory); err != nil {
+		t.Fatal(err)
+	}
+	if cpu.Steps() != 4 {
+		t.Fatalf("Steps() = %d after rerun, want 4", cpu.Steps())
+	}
+}
+
+func TestVMFaults(t *testing.T) {
+	cases := []struct {
+		name string
+		src  string
+		pc   int
+		err  error
+	}{
+		{"divide", "loadi r1, 1\nloadi r2, 0\ndiv r0, r1, r2\nhalt", 8, ErrDivideByZero},
+		{"load", "loadi r1, -4\nload r0, [r1]\nhalt", 4, ErrMemoryFault},
+		{"ret", "ret", 0, ErrMemoryFault}, // 栈是空的
+		{"syscall", "sys 9\nhalt", 0, ErrUnknownSyscall},
+		{"illegal", "jmp data\ndata: .word 0xff", 4, ErrIllegalInstruction},
+		{"run off the end", "jmp 32766", 32766, ErrMemoryFault},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			recordOutput(t)
+			cpu, memory := bootProgram(t, tc.src)
+			timeline := NewTimeline()
+			cpu.attach(timeline)
+
+			err := cpu.Execute(context.Background(), memory)
+			var fault *CPUFault
+			if !errors.As(err, &fault) || !errors.Is(err, tc.err) {
+				t.Fatalf("err = %v, want a fault with %v", err, tc.err)
+			}
+			if fault.PC != tc.pc {
+				t.Fatalf("fault at %#x, want %#x", fault.PC, tc.pc)
+			}
+			if cpu.State() != StateHalted {
+				t.Fatalf("state = %s, want halted", cpu.State())
+			}
+			events := timeline.Events()
+			if last := events[len(events)-1]; last.Op != "fault" || !errors.Is(last.Err, tc.err) {
+				t.Fatalf("last event = %+v, want the fault", last)
+			}
+		})
+	}
+}
+
+func TestVMRunCanceled(t *testing.T) {
+	recordOutput(t)
+	cpu, memory := bootProgram(t, "loop: jmp loop")
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := cpu.Execute(ctx, memory); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want DeadlineExceeded", err)
+	}
+	if cpu.State() != StateExecuting || cpu.Steps() == 0 {
+		t.Fatalf("state = %s after %d steps, want executing", cpu.State(), cpu.Steps())
+	}
+	// 关机后 Step 失败
+	cpu.Shutdown()
+	if _, err := cpu.Step(); !errors.Is(err, ErrNotExecuting) {
+		t.Fatalf("Step after Shutdown err = %v, want ErrNotExecuting", err)
+	}
+}
+
+func TestMemoryAccess(t *testing.T) {
+	recordOutput(t)
+	memory := &Memory{}
+	if _, err := memory.Peek(0); !errors.Is(err, ErrMemoryNotLoaded) {
+		t.Fatalf("Peek before Load err = %v, want ErrMemoryNotLoaded", err)
+	}
+	if err := memory.Load(make([]byte, MemorySize+1)); !errors.Is(err, ErrImageTooLarge) {
+		t.Fatalf("Load err = %v, want ErrImageTooLarge", err)
+	}
+	memory.Load([]byte{1, 2})
+	if err := memory.PokeWord(100, -2); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := memory.PeekWord(100); v != -2 {
+		t.Fatalf("PeekWord = %d, want -2", v)
+	}
+	if b, _ := memory.Peek(1); b != 2 {
+		t.Fatalf("Peek(1) = %d, want 2", b)
+	}
+	if err := memory.PokeWord(MemorySize-3, 1); !errors.Is(err, ErrMemoryFault) {
+		t.Fatalf("PokeWord at the end err = %v, want ErrMemoryFault", err)
+	}
+}
+
+func TestFacadeRunsProgram(t *testing.T) {
+	recorder := recordOutput(t)
+	image, err := Assemble(factorialProgram)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "fact.bin")
+	if err := os.WriteFile(path, image, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cpu := &CPU{}
+	facade := NewComputerFacadeWith(cpu, &Memory{}, NewHardDrive(path))
+	if err := facade.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if got := programOutput(recorder); !reflect.DeepEqual(got, []string{"3628800"}) {
+		t.Fatalf("output = %q", got)
+	}
+	if cpu.State() != StateHalted {
+		t.Fatalf("cpu state = %s, want halted", cpu.State())
+	}
+	if err := facade.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFacadeProgramErrors(t *testing.T) {
+	recordOutput(t)
+	dir := t.TempDir()
+
+	// 镜像文件不存在
+	cpu, memory := &CPU{}, &Memory{}
+	hardDrive := NewHardDrive(filepath.Join(dir, "missing.bin"))
+	facade := NewComputerFacadeWith(cpu, memory, hardDrive)
+	if err := facade.Start(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Start err = %v, want ErrNotExist", err)
+	}
+	if cpu.State() != StateOff || memory.State() != StateEmpty || hardDrive.State() != StateParked {
+		t.Fatalf("states after failed Start: cpu %s, memory %s, drive %s", cpu.State(), memory.State(), hardDrive.State())
+	}
+
+	// 程序出错时回滚
+	image, _ := Assemble("loadi r1, 0\ndiv r0, r1, r1")
+	path := filepath.Join(dir, "div.bin")
+	if err := os.WriteFile(path, image, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cpu, memory = &CPU{}, &Memory{}
+	hardDrive = NewHardDrive(path)
+	facade = NewComputerFacadeWith(cpu, memory, hardDrive)
+	err := facade.Start()
+	var fault *CPUFault
+	if !errors.As(err, &fault) || !errors.Is(err, ErrDivideByZero) {
+		t.Fatalf("Start err = %v, want a divide-by-zero fault", err)
+	}
+	if cpu.State() != StateOff || memory.State() != StateEmpty || hardDrive.State() != StateParked {
+		t.Fatalf("states after rollback: cpu %s, memory %s, drive %s", cpu.State(), memory.State(), hardDrive.State())
+	}
+
+	// 程序只受调用方的 ctx 限制，而不是组件的启动超时
+	image, _ = Assemble("loop: jmp loop")
+	path = filepath.Join(dir, "loop.bin")
+	if err := os.WriteFile(path, image, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cpu, memory = &CPU{}, &Memory{}
+	hardDrive = NewHardDrive(path)
+	facade = NewComputerFacadeWith(cpu, memory, hardDrive)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = facade.StartContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrComponentTimeout) {
+		t.Fatalf("StartContext err = %v, want DeadlineExceeded from the program", err)
+	}
+	if cpu.State() != StateOff || memory.State() != StateEmpty || hardDrive.State() != StateParked {
+		t.Fatalf("states after cancel: cpu %s, memory %s, drive %s", cpu.State(), memory.State(), hardDrive.State())
+	}
+}
@@ -1,9 +1,204 @@
 package designpattern
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// assertGroups 检查 got 依次由 groups 组成，每组内的顺序不限
+func assertGroups(t *testing.T, got []string, groups ...[]string) {
+	t.Helper()
+	rest := got
+	for _, group := range groups {
+		if len(rest) < len(group) {
+			t.Fatalf("got %q, want groups %q", got, groups)
+		}
+		head := append([]string(nil), rest[:len(group)]...)
+		want := append([]string(nil), group...)
+		sort.Strings(head)
+		sort.Strings(want)
+		if !reflect.DeepEqual(head, want) {
+			t.Fatalf("got %q, want groups %q", got, groups)
+		}
+		rest = rest[len(group):]
+	}
+	if len(rest) != 0 {
+		t.Fatalf("got %q, want groups %q", got, groups)
+	}
+}
 
 func TestFacade(t *testing.T) {
+	recorder := recordOutput(t)
+	facade := NewComputerFacade()
+	if err := facade.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := facade.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+
+	assertMessages(t, recorder,
+		"CPU is starting",
+		"HardDrive is reading",
+		"Memory is loading",
+		"CPU is executing",
+		"Memory is unloading",
+		"HardDrive is writing",
+		"HardDrive is parking",
+		"CPU is shutting down",
+	)
+}
+
+func TestFacadeTimeline(t *testing.T) {
+	recordOutput(t)
+	facade := NewComputerFacade()
+	facade.Start()
+	facade.Shutdown()
+
+	events := facade.Timeline().Events()
+	var got []string
+	for i, event := range events {
+		if event.Err != nil {
+			t.Errorf("event %d failed: %v", i, event.Err)
+		}
+		if event.Time.IsZero() || i > 0 && event.Time.Before(events[i-1].Time) {
+			t.Errorf("event %d has time %v out of order", i, event.Time)
+		}
+		got = append(got, fmt.Sprintf("%s %s %s->%s", event.Component, event.Op, event.From, event.To))
+	}
+	// 空硬盘读出空镜像，内存全为 0，第一条指令就是 halt
+	want := []string{
+		"CPU start off->on",
+		"HardDrive read parked->spinning",
+		"Memory load empty->loaded",
+		"CPU execute on->executing",
+		"CPU halt executing->halted",
+		"Memory unload loaded->empty",
+		"HardDrive write spinning->spinning",
+		"HardDrive park spinning->parked",
+		"CPU shutdown halted->off",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("timeline = %q, want %q", got, want)
+	}
+}
+
+func TestFacadeIllegalOperations(t *testing.T) {
+	recordOutput(t)
+	timeline := NewTimeline()
+	cpu := &CPU{subsystem: subsystem{timeline: timeline}}
+	memory := &Memory{}
+
+	if err := cpu.Execute(context.Background(), memory); !errors.Is(err, ErrPoweredOff) {
+		t.Fatalf("Execute while off err = %v, want ErrPoweredOff", err)
+	}
+	cpu.Start()
+	err := cpu.Execute(context.Background(), memory)
+	var subsystemErr *SubsystemError
+	if !errors.As(err, &subsystemErr) || !errors.Is(err, ErrMemoryNotLoaded) {
+		t.Fatalf("Execute before Load err = %v, want ErrMemoryNotLoaded", err)
+	}
+	if subsystemErr.Component != "CPU" || subsystemErr.Op != "execute" || subsystemErr.State != StateOn {
+		t.Fatalf("error = %+v", subsystemErr)
+	}
+	if cpu.State() != StateOn {
+		t.Fatalf("state after rejected Execute = %s, want on", cpu.State())
+	}
+	if err := cpu.Start(); !errors.Is(err, ErrAlreadyRunning) {
+		t.Fatalf("second Start err = %v, want ErrAlreadyRunning", err)
+	}
+	if err := memory.Unload(); !errors.Is(err, ErrMemoryNotLoaded) {
+		t.Fatalf("Unload while empty err = %v, want ErrMemoryNotLoaded", err)
+	}
+	if err := (&HardDrive{}).Write(); !errors.Is(err, ErrDriveParked) {
+		t.Fatalf("Write while parked err = %v, want ErrDriveParked", err)
+	}
+
+	// 失败的操作也记录在 timeline 中，memory 没有 timeline 所以不记录
+	events := timeline.Events()
+	if len(events) != 4 || events[1].Op != "start" || events[2].Err == nil || events[3].Err == nil {
+		t.Fatalf("timeline = %+v", events)
+	}
+
 	facade := NewComputerFacade()
+	if err := facade.Shutdown(); !errors.Is(err, ErrComputerNotReady) {
+		t.Fatalf("Shutdown before Start err = %v, want ErrComputerNotReady", err)
+	}
 	facade.Start()
 	facade.Shutdown()
+	if err := facade.Shutdown(); !errors.Is(err, ErrComputerNotReady) {
+		t.Fatalf("second Shutdown err = %v, want ErrComputerNotReady", err)
+	}
+}
+
+func TestFacadeStartRollback(t *testing.T) {
+	recordOutput(t)
+	cpu, memory := &CPU{}, &Memory{}
+	memory.Load(nil)
+	facade := NewComputerFacadeWith(cpu, memory, &HardDrive{})
+
+	if err := facade.Start(); !errors.Is(err, ErrMemoryLoaded) {
+		t.Fatalf("Start err = %v, want ErrMemoryLoaded", err)
+	}
+	if cpu.State() != StateOff {
+		t.Fatalf("cpu state = %s after failed Start, want off", cpu.State())
+	}
+	if memory.State() != StateLoaded {
+		t.Fatalf("memory state = %s, want untouched", memory.State())
+	}
+}
+
+func TestFacadeConcurrent(t *testing.T) {
+	recordOutput(t)
+	facade := NewComputerFacade()
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		started int
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := facade.Start()
+			if err != nil && !errors.Is(err, ErrAlreadyRunning) {
+				t.Error(err)
+			}
+			if err == nil {
+				mu.Lock()
+				started++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if started != 1 {
+		t.Fatalf("%d concurrent Start calls succeeded, want 1", started)
+	}
+
+	var shutdowns int
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := facade.Shutdown()
+			if err != nil && !errors.Is(err, ErrComputerNotReady) {
+				t.Error(err)
+			}
+			if err == nil {
+				mu.Lock()
+				shutdowns++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if shutdowns != 1 {
+		t.Fatalf("%d concurrent Shutdown calls succeeded, want 1", shutdowns)
+	}
 }
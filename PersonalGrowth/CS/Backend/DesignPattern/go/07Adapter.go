@@ -1,79 +1,278 @@
 package designpattern
 
-// MediaPlayer 是目标接口
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+// 适配器模式
+// AudioPlayer 根据文件头的魔数识别格式，再交给注册表中该格式的解码器播放。
+// Mp4Player 的接口与 MediaPlayer 不兼容，通过 MediaAdapter 适配后注册为 MP4 解码器，
+// WAV 由 WAVPlayer（见 07AdapterWAV.go）解码。
+
+var (
+	// ErrUnsupportedFormat 表示无法识别格式或者该格式没有注册解码器
+	ErrUnsupportedFormat = errors.New("unsupported audio format")
+	// ErrCodecExists 表示该格式已经注册过解码器
+	ErrCodecExists = errors.New("codec already registered")
+)
+
+// AudioFormat 音频格式
+type AudioFormat string
+
+const (
+	AudioFormatMP3  AudioFormat = "mp3"
+	AudioFormatMP4  AudioFormat = "mp4"
+	AudioFormatWAV  AudioFormat = "wav"
+	AudioFormatFLAC AudioFormat = "flac"
+	AudioFormatOgg  AudioFormat = "ogg"
+)
+
+// audioSniffLen 识别格式需要的文件头长度
+const audioSniffLen = 12
+
+// audioSignatures 按顺序匹配文件头，MP3 帧同步的条件最宽松，放在最后
+var audioSignatures = []struct {
+	format AudioFormat
+	match  func(header []byte) bool
+}{
+	{AudioFormatMP3, func(h []byte) bool { return bytes.HasPrefix(h, []byte("ID3")) }},
+	{AudioFormatMP4, func(h []byte) bool { return len(h) >= 8 && string(h[4:8]) == "ftyp" }},
+	{AudioFormatWAV, func(h []byte) bool {
+		return len(h) >= 12 && string(h[:4]) == "RIFF" && string(h[8:12]) == "WAVE"
+	}},
+	{AudioFormatFLAC, func(h []byte) bool { return bytes.HasPrefix(h, []byte("fLaC")) }},
+	{AudioFormatOgg, func(h []byte) bool { return bytes.HasPrefix(h, []byte("OggS")) }},
+	{AudioFormatMP3, isMP3FrameSync},
+}
+
+// isMP3FrameSync 检查 MPEG 音频帧头：11 位同步字，layer、码率和采样率不能是保留值
+func isMP3FrameSync(h []byte) bool {
+	if len(h) < 3 || h[0] != 0xFF || h[1]&0xE0 != 0xE0 {
+		return false
+	}
+	layer := (h[1] >> 1) & 0x03
+	bitrate := h[2] >> 4
+	sampleRate := (h[2] >> 2) & 0x03
+	return layer != 0 && bitrate != 0x0F && sampleRate != 0x03
+}
+
+// DetectAudioFormat 根据文件头识别格式
+func DetectAudioFormat(header []byte) (AudioFormat, error) {
+	for _, sig := range audioSignatures {
+		if sig.match(header) {
+			return sig.format, nil
+		}
+	}
+	return "", fmt.Errorf("%w: unrecognized header % x", ErrUnsupportedFormat, header[:min(len(header), audioSniffLen)])
+}
+
+// MediaPlayer 是目标接口，解码器也实现这个接口
 type MediaPlayer interface {
-    Play() 
+	Play(r io.Reader) error
+}
+
+// MediaPlayerFunc 把函数适配为 MediaPlayer
+type MediaPlayerFunc func(r io.Reader) error
+
+func (f MediaPlayerFunc) Play(r io.Reader) error {
+	return f(r)
 }
 
 // AdvancedMediaPlayer 是需要适配的高级播放器接口
 type AdvancedMediaPlayer interface {
-    PlayVlc()
-    PlayMp4()
+	PlayVlc(r io.Reader) error
+	PlayMp4(r io.Reader) error
 }
 
 // VlcPlayer 实现高级播放器接口
 type VlcPlayer struct{}
 
-func (v *VlcPlayer) PlayVlc() {
-    println("Playing vlc file")
+func (v *VlcPlayer) PlayVlc(r io.Reader) error {
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return err
+	}
+	emit("VlcPlayer", "Playing vlc file")
+	return nil
 }
 
-func (v *VlcPlayer) PlayMp4() {
-    // 不支持MP4
+func (v *VlcPlayer) PlayMp4(r io.Reader) error {
+	return fmt.Errorf("%w: VlcPlayer does not play mp4", ErrUnsupportedFormat)
 }
 
 // Mp4Player 实现高级播放器接口
 type Mp4Player struct{}
 
-func (m *Mp4Player) PlayVlc() {
-    // 不支持VLC
+func (m *Mp4Player) PlayVlc(r io.Reader) error {
+	return fmt.Errorf("%w: Mp4Player does not play vlc", ErrUnsupportedFormat)
 }
 
-func (m *Mp4Player) PlayMp4() {
-    println("Playing mp4 file")
+func (m *Mp4Player) PlayMp4(r io.Reader) error {
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return err
+	}
+	emit("Mp4Player", "Playing mp4 file")
+	return nil
 }
 
-// MediaAdapter 是适配器
+// MediaAdapter 是适配器，把 AdvancedMediaPlayer 的一个播放方法适配为 MediaPlayer
 type MediaAdapter struct {
-    advancedMediaPlayer AdvancedMediaPlayer
+	play func(r io.Reader) error
+}
+
+// NewVlcAdapter 使用 player 的 PlayVlc 播放
+func NewVlcAdapter(player AdvancedMediaPlayer) *MediaAdapter {
+	return &MediaAdapter{play: player.PlayVlc}
+}
+
+// NewMp4Adapter 使用 player 的 PlayMp4 播放
+func NewMp4Adapter(player AdvancedMediaPlayer) *MediaAdapter {
+	return &MediaAdapter{play: player.PlayMp4}
+}
+
+func (m *MediaAdapter) Play(r io.Reader) error {
+	return m.play(r)
+}
+
+// CodecRegistry 按格式保存解码器，可并发使用
+type CodecRegistry struct {
+	mu     sync.RWMutex
+	codecs map[AudioFormat]MediaPlayer
 }
 
-func NewMediaAdapter(audioType string) *MediaAdapter {
-    var advancedMediaPlayer AdvancedMediaPlayer
-    if audioType == "vlc" {
-        advancedMediaPlayer = &VlcPlayer{}
-    } else if audioType == "mp4" {
-        advancedMediaPlayer = &Mp4Player{}
-    }
-    return &MediaAdapter{advancedMediaPlayer}
+func NewCodecRegistry() *CodecRegistry {
+	return &CodecRegistry{codecs: make(map[AudioFormat]MediaPlayer)}
 }
 
-func (m *MediaAdapter) Play() {
-    switch m.advancedMediaPlayer.(type) {
-    case *VlcPlayer:
-        m.advancedMediaPlayer.PlayVlc()
-    case *Mp4Player:
-        m.advancedMediaPlayer.PlayMp4()
-    }
+// Register 注册解码器，格式重复时返回 ErrCodecExists
+func (r *CodecRegistry) Register(format AudioFormat, codec MediaPlayer) error {
+	if codec == nil {
+		return fmt.Errorf("codec %q is nil", format)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.codecs[format]; ok {
+		return fmt.Errorf("%w: %q", ErrCodecExists, format)
+	}
+	r.codecs[format] = codec
+	return nil
+}
+
+// Unregister 删除解码器，返回该格式之前是否已注册
+func (r *CodecRegistry) Unregister(format AudioFormat) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.codecs[format]; !ok {
+		return false
+	}
+	delete(r.codecs, format)
+	return true
+}
+
+// Formats 返回已注册的格式，按字母排序
+func (r *CodecRegistry) Formats() []AudioFormat {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	formats := make([]AudioFormat, 0, len(r.codecs))
+	for format := range r.codecs {
+		formats = append(formats, format)
+	}
+	sort.Slice(formats, func(i, j int) bool { return formats[i] < formats[j] })
+	return formats
+}
+
+// Lookup 返回格式的解码器，未注册时返回 ErrUnsupportedFormat
+func (r *CodecRegistry) Lookup(format AudioFormat) (MediaPlayer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	codec, ok := r.codecs[format]
+	if !ok {
+		return nil, fmt.Errorf("%w: no codec for %s", ErrUnsupportedFormat, format)
+	}
+	return codec, nil
+}
+
+// 默认注册表，供包级函数和 NewAudioPlayer 使用
+var codecRegistry = NewCodecRegistry()
+
+func init() {
+	RegisterCodec(AudioFormatMP3, MediaPlayerFunc(playMP3))
+	RegisterCodec(AudioFormatMP4, NewMp4Adapter(&Mp4Player{}))
+	RegisterCodec(AudioFormatWAV, &WAVPlayer{})
+	// FLAC 和 Ogg 能识别但没有解码器，播放时返回 ErrUnsupportedFormat
+}
+
+// playMP3 AudioPlayer 自带的 MP3 解码器
+func playMP3(r io.Reader) error {
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return err
+	}
+	emit("AudioPlayer", "Playing mp3 file")
+	return nil
+}
+
+// RegisterCodec 向默认注册表注册解码器
+func RegisterCodec(format AudioFormat, codec MediaPlayer) error {
+	return codecRegistry.Register(format, codec)
+}
+
+// UnregisterCodec 从默认注册表删除解码器
+func UnregisterCodec(format AudioFormat) bool {
+	return codecRegistry.Unregister(format)
+}
+
+// Codecs 返回默认注册表中的格式
+func Codecs() []AudioFormat {
+	return codecRegistry.Formats()
 }
 
 // AudioPlayer 是实现了 MediaPlayer 接口的播放器
 type AudioPlayer struct {
-    mediaAdapter *MediaAdapter
+	codecs *CodecRegistry
 }
 
-func (a *AudioPlayer) Play() {
-    if a.mediaAdapter != nil {
-        a.mediaAdapter.Play()
-    } else {
-        println("Playing mp3 file")
-    }
+// NewAudioPlayer 使用默认注册表中的解码器
+func NewAudioPlayer() *AudioPlayer {
+	return NewAudioPlayerWith(codecRegistry)
 }
 
-func NewAudioPlayer(audioType string) *AudioPlayer {
-    var adapter *MediaAdapter
-    if audioType == "vlc" || audioType == "mp4" {
-        adapter = NewMediaAdapter(audioType)
-    }
-    return &AudioPlayer{adapter}
-}
\ No newline at end of file
+func NewAudioPlayerWith(codecs *CodecRegistry) *AudioPlayer {
+	return &AudioPlayer{codecs: codecs}
+}
+
+// Play 识别 r 的格式后交给对应的解码器，解码器从头读取完整的数据
+func (a *AudioPlayer) Play(r io.Reader) error {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(audioSniffLen)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	format, err := DetectAudioFormat(header)
+	if err != nil {
+		return err
+	}
+	codec, err := a.codecs.Lookup(format)
+	if err != nil {
+		return err
+	}
+	return codec.Play(br)
+}
+
+// PlayFile 播放文件
+func (a *AudioPlayer) PlayFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := a.Play(f); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
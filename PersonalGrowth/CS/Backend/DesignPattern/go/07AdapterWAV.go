@@ -0,0 +1,312 @@
+package designpattern
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// WAV 解码
+// WAVDecoder 解析 RIFF 块，支持 8/16/24/32 位整数 PCM 和 32/64 位 IEEE 浮点，
+// 可以逐帧迭代（Next/Frame/Err），也可以通过 PCM16 读取交错的 16 位小端 PCM。
+// WAVPlayer 把解码器适配为 MediaPlayer，注册为默认的 WAV 解码器。
+
+var ErrInvalidWAV = errors.New("invalid wav file")
+
+// WAVEncoding fmt 块中的格式标签
+type WAVEncoding uint16
+
+const (
+	WAVEncodingPCM   WAVEncoding = 1
+	WAVEncodingFloat WAVEncoding = 3
+
+	// wavFormatExtensible 真正的格式标签在扩展部分的子格式 GUID 的前两个字节
+	wavFormatExtensible = 0xFFFE
+	// wavStreamingSize 边录边写的 WAV 在 data 块中写入的占位长度，数据一直读到 EOF
+	wavStreamingSize = 0xFFFFFFFF
+)
+
+func (e WAVEncoding) String() string {
+	switch e {
+	case WAVEncodingPCM:
+		return "PCM"
+	case WAVEncodingFloat:
+		return "IEEE float"
+	}
+	return fmt.Sprintf("format %#04x", uint16(e))
+}
+
+// WAVInfo 音频的格式，Frames 是 data 块中的帧数，每帧包含每个声道的一个采样，
+// 长度未知的流式 WAV 为 -1
+type WAVInfo struct {
+	Encoding      WAVEncoding
+	Channels      int
+	SampleRate    int
+	BitsPerSample int
+	Frames        int64
+}
+
+// Duration 返回播放时长，长度未知时返回 0
+func (i WAVInfo) Duration() time.Duration {
+	if i.SampleRate == 0 || i.Frames < 0 {
+		return 0
+	}
+	return time.Duration(i.Frames) * time.Second / time.Duration(i.SampleRate)
+}
+
+func (i WAVInfo) String() string {
+	duration := "streaming"
+	if i.Frames >= 0 {
+		duration = i.Duration().String()
+	}
+	return fmt.Sprintf("%d-bit %s, %d Hz, %d channels, %s", i.BitsPerSample, i.Encoding, i.SampleRate, i.Channels, duration)
+}
+
+// wavSampleDecoder 把一个采样转换为 [-1, 1] 内的浮点数
+type wavSampleDecoder func(b []byte) float64
+
+func newWAVSampleDecoder(encoding WAVEncoding, bits int) (wavSampleDecoder, error) {
+	switch {
+	case encoding == WAVEncodingPCM && bits == 8:
+		return func(b []byte) float64 { return float64(int(b[0])-128) / 128 }, nil
+	case encoding == WAVEncodingPCM && bits == 16:
+		return func(b []byte) float64 { return float64(int16(binary.LittleEndian.Uint16(b))) / (1 << 15) }, nil
+	case encoding == WAVEncodingPCM && bits == 24:
+		return func(b []byte) float64 {
+			v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8 // 符号扩展
+			return float64(v) / (1 << 23)
+		}, nil
+	case encoding == WAVEncodingPCM && bits == 32:
+		return func(b []byte) float64 { return float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31) }, nil
+	case encoding == WAVEncodingFloat && bits == 32:
+		return func(b []byte) float64 { return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))) }, nil
+	case encoding == WAVEncodingFloat && bits == 64:
+		return func(b []byte) float64 { return math.Float64frombits(binary.LittleEndian.Uint64(b)) }, nil
+	}
+	return nil, fmt.Errorf("%w: %d-bit %s wav", ErrUnsupportedFormat, bits, encoding)
+}
+
+// WAVDecoder 从 io.Reader 中流式解码 WAV，不可并发使用
+type WAVDecoder struct {
+	info      WAVInfo
+	data      io.Reader
+	remaining int64
+	sample    wavSampleDecoder
+	raw       []byte
+	frame     []float64
+	err       error
+}
+
+// NewWAVDecoder 读取 data 块之前的所有块，返回的解码器从第一帧开始
+func NewWAVDecoder(r io.Reader) (*WAVDecoder, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidWAV, err)
+	}
+	if string(header[:4]) != "RIFF" || string(header[8:]) != "WAVE" {
+		return nil, fmt.Errorf("%w: not a RIFF WAVE file", ErrInvalidWAV)
+	}
+
+	d := &WAVDecoder{}
+	haveFormat := false
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("%w: missing data chunk", ErrInvalidWAV)
+			}
+			return nil, fmt.Errorf("%w: %w", ErrInvalidWAV, err)
+		}
+		id := string(chunk[:4])
+		size := int64(binary.LittleEndian.Uint32(chunk[4:]))
+		switch id {
+		case "fmt ":
+			if haveFormat {
+				return nil, fmt.Errorf("%w: duplicate fmt chunk", ErrInvalidWAV)
+			}
+			if size < 16 || size > 1024 {
+				return nil, fmt.Errorf("%w: fmt chunk has %d bytes", ErrInvalidWAV, size)
+			}
+			body := make([]byte, size+size&1)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrInvalidWAV, err)
+			}
+			if err := d.parseFormat(body[:size]); err != nil {
+				return nil, err
+			}
+			haveFormat = true
+		case "data":
+			if !haveFormat {
+				return nil, fmt.Errorf("%w: data chunk before fmt chunk", ErrInvalidWAV)
+			}
+			if size == wavStreamingSize {
+				d.info.Frames, d.remaining, d.data = -1, -1, r
+				return d, nil
+			}
+			d.info.Frames = size / int64(len(d.raw))
+			d.remaining = d.info.Frames
+			d.data = io.LimitReader(r, d.info.Frames*int64(len(d.raw)))
+			return d, nil
+		default:
+			// 跳过 LIST 等其它块，块的长度是奇数时后面有一个填充字节
+			if _, err := io.CopyN(io.Discard, r, size+size&1); err != nil {
+				return nil, fmt.Errorf("%w: chunk %q: %w", ErrInvalidWAV, id, err)
+			}
+		}
+	}
+}
+
+func (d *WAVDecoder) parseFormat(b []byte) error {
+	encoding := WAVEncoding(binary.LittleEndian.Uint16(b[0:]))
+	channels := int(binary.LittleEndian.Uint16(b[2:]))
+	sampleRate := int(binary.LittleEndian.Uint32(b[4:]))
+	blockAlign := int(binary.LittleEndian.Uint16(b[12:]))
+	bits := int(binary.LittleEndian.Uint16(b[14:]))
+	if encoding == wavFormatExtensible {
+		if len(b) < 40 {
+			return fmt.Errorf("%w: extensible fmt chunk has %d bytes", ErrInvalidWAV, len(b))
+		}
+		encoding = WAVEncoding(binary.LittleEndian.Uint16(b[24:]))
+	}
+	if channels == 0 || sampleRate == 0 {
+		return fmt.Errorf("%w: %d channels at %d Hz", ErrInvalidWAV, channels, sampleRate)
+	}
+	sample, err := newWAVSampleDecoder(encoding, bits)
+	if err != nil {
+		return err
+	}
+	if blockAlign != channels*bits/8 {
+		return fmt.Errorf("%w: block align %d, want %d", ErrInvalidWAV, blockAlign, channels*bits/8)
+	}
+	d.info = WAVInfo{
+		Encoding:      encoding,
+		Channels:      channels,
+		SampleRate:    sampleRate,
+		BitsPerSample: bits,
+	}
+	d.sample = sample
+	d.raw = make([]byte, blockAlign)
+	d.frame = make([]float64, channels)
+	return nil
+}
+
+func (d *WAVDecoder) Info() WAVInfo {
+	return d.info
+}
+
+func (d *WAVDecoder) SampleRate() int {
+	return d.info.SampleRate
+}
+
+func (d *WAVDecoder) Channels() int {
+	return d.info.Channels
+}
+
+func (d *WAVDecoder) Duration() time.Duration {
+	return d.info.Duration()
+}
+
+// Next 解码下一帧，没有更多的帧或者出错时返回 false，之后用 Err 区分
+func (d *WAVDecoder) Next() bool {
+	if d.err != nil || d.remaining == 0 {
+		return false
+	}
+	if _, err := io.ReadFull(d.data, d.raw); err != nil {
+		switch {
+		case d.remaining < 0 && err == io.EOF:
+			// 流式 WAV 在帧边界上结束
+			d.remaining = 0
+			return false
+		case d.remaining < 0 && err == io.ErrUnexpectedEOF:
+			err = fmt.Errorf("%w: data chunk ends inside a frame", ErrInvalidWAV)
+		case err == io.EOF || err == io.ErrUnexpectedEOF:
+			err = fmt.Errorf("%w: data chunk truncated with %d frames left", ErrInvalidWAV, d.remaining)
+		}
+		d.err = err
+		return false
+	}
+	if d.remaining > 0 {
+		d.remaining--
+	}
+	width := d.info.BitsPerSample / 8
+	for ch := range d.frame {
+		d.frame[ch] = d.sample(d.raw[ch*width:])
+	}
+	return true
+}
+
+// Frame 返回 Next 解码的帧，每个声道一个 [-1, 1] 内的采样
+// 返回的切片在下一次调用 Next 时被覆盖
+func (d *WAVDecoder) Frame() []float64 {
+	return d.frame
+}
+
+// Err 返回迭代中的错误，正常结束时返回 nil
+func (d *WAVDecoder) Err() error {
+	return d.err
+}
+
+// PCM16 返回一个 io.Reader，把剩余的帧转换为交错的 16 位小端 PCM
+func (d *WAVDecoder) PCM16() io.Reader {
+	return &wavPCM16Reader{dec: d}
+}
+
+type wavPCM16Reader struct {
+	dec     *WAVDecoder
+	buf     []byte
+	pending []byte
+}
+
+func (r *wavPCM16Reader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(r.pending) == 0 {
+			if !r.dec.Next() {
+				if n > 0 {
+					return n, nil
+				}
+				if err := r.dec.Err(); err != nil {
+					return 0, err
+				}
+				return 0, io.EOF
+			}
+			r.buf = r.buf[:0]
+			for _, v := range r.dec.Frame() {
+				r.buf = binary.LittleEndian.AppendUint16(r.buf, uint16(toPCM16(v)))
+			}
+			r.pending = r.buf
+		}
+		c := copy(p[n:], r.pending)
+		r.pending = r.pending[c:]
+		n += c
+	}
+	return n, nil
+}
+
+// toPCM16 把 [-1, 1] 内的采样转换为 16 位整数，超出范围时截断
+func toPCM16(v float64) int16 {
+	s := math.Round(v * (1 << 15))
+	return int16(math.Max(math.MinInt16, math.Min(math.MaxInt16, s)))
+}
+
+// WAVPlayer 是适配器，把 WAVDecoder 适配为 MediaPlayer，解码后的 16 位 PCM 写到 Output
+type WAVPlayer struct {
+	Output io.Writer // nil 时丢弃
+}
+
+func (p *WAVPlayer) Play(r io.Reader) error {
+	dec, err := NewWAVDecoder(r)
+	if err != nil {
+		return err
+	}
+	emitf("WAVPlayer", "Playing wav file (%s)", dec.Info())
+	out := p.Output
+	if out == nil {
+		out = io.Discard
+	}
+	_, err = io.Copy(out, dec.PCM16())
+	return err
+}
@@ -0,0 +1,326 @@
+package designpattern
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testWAV 描述测试中在内存里生成的 WAV 文件
+type testWAV struct {
+	encoding   WAVEncoding
+	bits       int
+	sampleRate int
+	frames     [][]float64
+	extensible bool   // 使用 WAVE_FORMAT_EXTENSIBLE 的 fmt 块
+	extra      []byte // 写在 fmt 块之前的 LIST 块内容
+}
+
+func (w testWAV) encode(t *testing.T) []byte {
+	t.Helper()
+	channels := len(w.frames[0])
+	width := w.bits / 8
+
+	var data []byte
+	for _, frame := range w.frames {
+		for _, v := range frame {
+			data = appendWAVSample(t, data, w.encoding, w.bits, v)
+		}
+	}
+
+	var fmtChunk []byte
+	tag := uint16(w.encoding)
+	if w.extensible {
+		tag = wavFormatExtensible
+	}
+	fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, tag)
+	fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, uint16(channels))
+	fmtChunk = binary.LittleEndian.AppendUint32(fmtChunk, uint32(w.sampleRate))
+	fmtChunk = binary.LittleEndian.AppendUint32(fmtChunk, uint32(w.sampleRate*channels*width))
+	fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, uint16(channels*width))
+	fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, uint16(w.bits))
+	if w.extensible {
+		fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, 22)
+		fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, uint16(w.bits))
+		fmtChunk = binary.LittleEndian.AppendUint32(fmtChunk, 0) // 声道掩码
+		fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, uint16(w.encoding))
+		fmtChunk = append(fmtChunk, "\x00\x00\x00\x00\x10\x00\x80\x00\x00\xaa\x00\x38\x9b\x71"...)
+	}
+
+	var body []byte
+	body = append(body, "WAVE"...)
+	if w.extra != nil {
+		body = appendRIFFChunk(body, "LIST", w.extra)
+	}
+	body = appendRIFFChunk(body, "fmt ", fmtChunk)
+	body = appendRIFFChunk(body, "data", data)
+	return appendRIFFChunk(nil, "RIFF", body)
+}
+
+func appendRIFFChunk(b []byte, id string, body []byte) []byte {
+	b = append(b, id...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(body)))
+	b = append(b, body...)
+	if len(body)%2 == 1 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+func appendWAVSample(t *testing.T, b []byte, encoding WAVEncoding, bits int, v float64) []byte {
+	t.Helper()
+	quantize := func(scale float64) int64 {
+		return int64(math.Max(-scale, math.Min(scale-1, math.Round(v*scale))))
+	}
+	switch {
+	case encoding == WAVEncodingPCM && bits == 8:
+		return append(b, byte(quantize(1<<7)+128))
+	case encoding == WAVEncodingPCM && bits == 16:
+		return binary.LittleEndian.AppendUint16(b, uint16(quantize(1<<15)))
+	case encoding == WAVEncodingPCM && bits == 24:
+		s := uint32(quantize(1 << 23))
+		return append(b, byte(s), byte(s>>8), byte(s>>16))
+	case encoding == WAVEncodingPCM && bits == 32:
+		return binary.LittleEndian.AppendUint32(b, uint32(quantize(1<<31)))
+	case encoding == WAVEncodingFloat && bits == 32:
+		return binary.LittleEndian.AppendUint32(b, math.Float32bits(float32(v)))
+	case encoding == WAVEncodingFloat && bits == 64:
+		return binary.LittleEndian.AppendUint64(b, math.Float64bits(v))
+	}
+	t.Fatalf("cannot encode %d-bit %s", bits, encoding)
+	return nil
+}
+
+// sineFrames 生成 n 帧的立体声正弦波，右声道反相
+func sineFrames(n int) [][]float64 {
+	frames := make([][]float64, n)
+	for i := range frames {
+		v := 0.9 * math.Sin(2*math.Pi*float64(i)/50)
+		frames[i] = []float64{v, -v}
+	}
+	return frames
+}
+
+func TestWAVDecoderRoundTrip(t *testing.T) {
+	cases := []struct {
+		encoding WAVEncoding
+		bits     int
+	}{
+		{WAVEncodingPCM, 8},
+		{WAVEncodingPCM, 16},
+		{WAVEncodingPCM, 24},
+		{WAVEncodingPCM, 32},
+		{WAVEncodingFloat, 32},
+		{WAVEncodingFloat, 64},
+	}
+	frames := sineFrames(200)
+	frames = append(frames, []float64{-1, 0.5}) // 负的满量程可以精确表示
+	for _, tc := range cases {
+		for _, extensible := range []bool{false, true} {
+			w := testWAV{encoding: tc.encoding, bits: tc.bits, sampleRate: 8000, frames: frames, extensible: extensible}
+			dec, err := NewWAVDecoder(bytes.NewReader(w.encode(t)))
+			if err != nil {
+				t.Fatalf("%d-bit %s: %v", tc.bits, tc.encoding, err)
+			}
+			want := WAVInfo{Encoding: tc.encoding, Channels: 2, SampleRate: 8000, BitsPerSample: tc.bits, Frames: int64(len(frames))}
+			if dec.Info() != want {
+				t.Fatalf("Info() = %+v, want %+v", dec.Info(), want)
+			}
+
+			tolerance := 1e-7 // float32 的精度
+			if tc.encoding == WAVEncodingPCM {
+				// 整数 PCM 的量化误差不超过半个最低位
+				tolerance = 1 / math.Pow(2, float64(tc.bits))
+			}
+			i := 0
+			for dec.Next() {
+				for ch, got := range dec.Frame() {
+					if math.Abs(got-frames[i][ch]) > tolerance {
+						t.Fatalf("%d-bit %s frame %d channel %d = %v, want %v", tc.bits, tc.encoding, i, ch, got, frames[i][ch])
+					}
+				}
+				i++
+			}
+			if err := dec.Err(); err != nil || i != len(frames) {
+				t.Fatalf("%d-bit %s decoded %d frames, err = %v", tc.bits, tc.encoding, i, err)
+			}
+		}
+	}
+}
+
+func TestWAVDecoderPCM16(t *testing.T) {
+	w := testWAV{encoding: WAVEncodingPCM, bits: 16, sampleRate: 44100, frames: sineFrames(441)}
+	file := w.encode(t)
+	dec, err := NewWAVDecoder(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dec.SampleRate() != 44100 || dec.Channels() != 2 || dec.Duration() != 10*time.Millisecond {
+		t.Fatalf("SampleRate() = %d, Channels() = %d, Duration() = %v", dec.SampleRate(), dec.Channels(), dec.Duration())
+	}
+
+	// 先用迭代器取一帧，剩余的帧从 io.Reader 读出，读取的块大小不是帧大小的整数倍
+	if !dec.Next() {
+		t.Fatal(dec.Err())
+	}
+	var out bytes.Buffer
+	if _, err := io.CopyBuffer(&out, struct{ io.Reader }{dec.PCM16()}, make([]byte, 7)); err != nil {
+		t.Fatal(err)
+	}
+	// 16 位的 PCM 原样还原 data 块中第一帧之后的字节
+	data := file[len(file)-441*4:]
+	if !bytes.Equal(out.Bytes(), data[4:]) {
+		t.Fatalf("PCM16 produced %d bytes, want %d identical bytes", out.Len(), len(data)-4)
+	}
+
+	// 浮点超出 [-1, 1] 的采样被截断
+	w = testWAV{encoding: WAVEncodingFloat, bits: 32, sampleRate: 8000, frames: [][]float64{{1.5}, {-2}, {0.25}}}
+	dec, err = NewWAVDecoder(bytes.NewReader(w.encode(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pcm, err := io.ReadAll(dec.PCM16())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int16{math.MaxInt16, math.MinInt16, 8192}
+	for i, v := range want {
+		if got := int16(binary.LittleEndian.Uint16(pcm[2*i:])); got != v {
+			t.Fatalf("sample %d = %d, want %d", i, got, v)
+		}
+	}
+}
+
+func TestWAVDecoderSkipsChunks(t *testing.T) {
+	// LIST 块的长度是奇数，后面有填充字节
+	w := testWAV{encoding: WAVEncodingPCM, bits: 8, sampleRate: 11025, frames: [][]float64{{0}, {0.5}, {-0.5}}, extra: []byte("INFOISFT\x03\x00\x00\x00go\x00")}
+	dec, err := NewWAVDecoder(bytes.NewReader(w.encode(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []float64
+	for dec.Next() {
+		got = append(got, dec.Frame()[0])
+	}
+	if dec.Err() != nil || len(got) != 3 || got[1] != 0.5 || got[2] != -0.5 {
+		t.Fatalf("frames = %v, err = %v", got, dec.Err())
+	}
+}
+
+func TestWAVDecoderErrors(t *testing.T) {
+	valid := testWAV{encoding: WAVEncodingPCM, bits: 16, sampleRate: 8000, frames: sineFrames(10)}.encode(t)
+	fmtStart := bytes.Index(valid, []byte("fmt "))
+	dataStart := bytes.Index(valid, []byte("data"))
+	patch := func(offset int, b ...byte) []byte {
+		file := append([]byte(nil), valid...)
+		copy(file[offset:], b)
+		return file
+	}
+
+	cases := []struct {
+		name string
+		file []byte
+		err  error
+	}{
+		{"empty", nil, ErrInvalidWAV},
+		{"not wave", patch(8, 'A', 'V', 'I', ' '), ErrInvalidWAV},
+		{"no data", valid[:dataStart], ErrInvalidWAV},
+		{"data before fmt", append(append([]byte(nil), valid[:fmtStart]...), valid[dataStart:]...), ErrInvalidWAV},
+		{"truncated chunk", valid[:fmtStart+10], ErrInvalidWAV},
+		{"adpcm", patch(fmtStart+8, 2, 0), ErrUnsupportedFormat},
+		{"12-bit", patch(fmtStart+22, 12, 0), ErrUnsupportedFormat},
+		{"no channels", patch(fmtStart+10, 0, 0), ErrInvalidWAV},
+		{"block align", patch(fmtStart+20, 3, 0), ErrInvalidWAV},
+	}
+	for _, tc := range cases {
+		if _, err := NewWAVDecoder(bytes.NewReader(tc.file)); !errors.Is(err, tc.err) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.err)
+		}
+	}
+
+	// data 块声明的长度超过实际的数据
+	dec, err := NewWAVDecoder(bytes.NewReader(valid[:len(valid)-6]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for dec.Next() {
+		n++
+	}
+	if n != 8 || !errors.Is(dec.Err(), ErrInvalidWAV) {
+		t.Fatalf("decoded %d frames, err = %v, want 8 frames and ErrInvalidWAV", n, dec.Err())
+	}
+	if _, err := io.ReadAll(dec.PCM16()); !errors.Is(err, ErrInvalidWAV) {
+		t.Fatalf("PCM16 err = %v, want ErrInvalidWAV", err)
+	}
+}
+
+func TestWAVDecoderStreaming(t *testing.T) {
+	frames := sineFrames(100)
+	file := testWAV{encoding: WAVEncodingPCM, bits: 16, sampleRate: 8000, frames: frames}.encode(t)
+	// 边录边写的文件在 RIFF 和 data 块中写入占位长度
+	binary.LittleEndian.PutUint32(file[4:], wavStreamingSize)
+	dataStart := bytes.Index(file, []byte("data"))
+	binary.LittleEndian.PutUint32(file[dataStart+4:], wavStreamingSize)
+
+	dec, err := NewWAVDecoder(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info := dec.Info(); info.Frames != -1 || info.Duration() != 0 || !strings.HasSuffix(info.String(), "streaming") {
+		t.Fatalf("Info() = %+v (%s), want unknown length", info, info)
+	}
+	n := 0
+	for dec.Next() {
+		n++
+	}
+	if err := dec.Err(); err != nil || n != len(frames) {
+		t.Fatalf("decoded %d frames, err = %v, want %d frames", n, err, len(frames))
+	}
+
+	// 流在帧的中间结束
+	dec, err = NewWAVDecoder(bytes.NewReader(file[:len(file)-1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for dec.Next() {
+	}
+	if !errors.Is(dec.Err(), ErrInvalidWAV) {
+		t.Fatalf("err = %v, want ErrInvalidWAV", dec.Err())
+	}
+}
+
+func TestWAVPlayer(t *testing.T) {
+	recorder := recordOutput(t)
+	file := testWAV{encoding: WAVEncodingPCM, bits: 24, sampleRate: 48000, frames: sineFrames(12000)}.encode(t)
+
+	// 默认注册的 WAV 解码器
+	if err := NewAudioPlayer().Play(bytes.NewReader(file)); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	registry := NewCodecRegistry()
+	if err := registry.Register(AudioFormatWAV, &WAVPlayer{Output: &out}); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewAudioPlayerWith(registry).Play(bytes.NewReader(file)); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 12000*2*2 {
+		t.Fatalf("Output got %d bytes, want %d", out.Len(), 12000*2*2)
+	}
+	assertMessages(t, recorder,
+		"Playing wav file (24-bit PCM, 48000 Hz, 2 channels, 250ms)",
+		"Playing wav file (24-bit PCM, 48000 Hz, 2 channels, 250ms)",
+	)
+
+	if err := NewAudioPlayer().Play(bytes.NewReader(wavHeader)); !errors.Is(err, ErrInvalidWAV) {
+		t.Fatalf("Play(header only) err = %v, want ErrInvalidWAV", err)
+	}
+}
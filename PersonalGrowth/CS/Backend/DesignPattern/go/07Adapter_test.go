@@ -1,12 +1,153 @@
 package designpattern
 
-import "testing"
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 各种格式的文件头
+var (
+	id3Header  = []byte("ID3\x04\x00\x00\x00\x00\x00\x21rest")
+	mp3Frame   = []byte{0xFF, 0xFB, 0x90, 0x64, 0x00, 0x00}
+	mp4Header  = []byte("\x00\x00\x00\x18ftypmp42\x00\x00")
+	wavHeader  = []byte("RIFF\x24\x00\x00\x00WAVEfmt ")
+	flacHeader = []byte("fLaC\x00\x00\x00\x22")
+	oggHeader  = []byte("OggS\x00\x02\x00\x00")
+)
 
 func TestAdapter(t *testing.T) {
-	audioPlayer := NewAudioPlayer("mp3")
-	audioPlayer.Play()
-	audioPlayer = NewAudioPlayer("mp4")
-	audioPlayer.Play()
-	audioPlayer = NewAudioPlayer("vlc")
-	audioPlayer.Play()
+	recorder := recordOutput(t)
+	audioPlayer := NewAudioPlayer()
+	for _, data := range [][]byte{id3Header, mp4Header} {
+		if err := audioPlayer.Play(bytes.NewReader(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 能识别但没有默认解码器的格式
+	for _, data := range [][]byte{flacHeader, oggHeader} {
+		if err := audioPlayer.Play(bytes.NewReader(data)); !errors.Is(err, ErrUnsupportedFormat) {
+			t.Fatalf("Play(% x) err = %v, want ErrUnsupportedFormat", data[:4], err)
+		}
+	}
+
+	assertMessages(t, recorder, "Playing mp3 file", "Playing mp4 file")
+}
+
+func TestDetectAudioFormat(t *testing.T) {
+	cases := []struct {
+		header []byte
+		want   AudioFormat
+	}{
+		{id3Header, AudioFormatMP3},
+		{mp3Frame, AudioFormatMP3},
+		{mp4Header, AudioFormatMP4},
+		{wavHeader, AudioFormatWAV},
+		{flacHeader, AudioFormatFLAC},
+		{oggHeader, AudioFormatOgg},
+	}
+	for _, tc := range cases {
+		got, err := DetectAudioFormat(tc.header)
+		if err != nil || got != tc.want {
+			t.Errorf("DetectAudioFormat(% x) = %q, %v, want %q", tc.header, got, err, tc.want)
+		}
+	}
+
+	unsupported := [][]byte{
+		nil,
+		[]byte("hello, world"),
+		[]byte("RIFF\x24\x00\x00\x00AVI LIST"), // RIFF 但不是 WAVE
+		{0xFF, 0xF1, 0x50, 0x80},               // AAC ADTS，layer 为 0
+		{0xFF, 0xFB, 0xF0, 0x00},               // 码率为保留值
+	}
+	for _, header := range unsupported {
+		if _, err := DetectAudioFormat(header); !errors.Is(err, ErrUnsupportedFormat) {
+			t.Errorf("DetectAudioFormat(% x) err = %v, want ErrUnsupportedFormat", header, err)
+		}
+	}
+}
+
+func TestAudioPlayerCodecRegistry(t *testing.T) {
+	recordOutput(t)
+	var played []byte
+	registry := NewCodecRegistry()
+	err := registry.Register(AudioFormatFLAC, MediaPlayerFunc(func(r io.Reader) error {
+		var err error
+		played, err = io.ReadAll(r)
+		return err
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Register(AudioFormatFLAC, NewVlcAdapter(&VlcPlayer{})); !errors.Is(err, ErrCodecExists) {
+		t.Fatalf("duplicate Register err = %v, want ErrCodecExists", err)
+	}
+
+	player := NewAudioPlayerWith(registry)
+	data := append(append([]byte(nil), flacHeader...), strings.Repeat("x", 5000)...)
+	if err := player.Play(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	// 解码器从头收到完整的数据，包括用于识别格式的文件头
+	if !bytes.Equal(played, data) {
+		t.Fatalf("codec got %d bytes, want %d", len(played), len(data))
+	}
+
+	// 识别出格式但没有解码器
+	if err := player.Play(bytes.NewReader(oggHeader)); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("Play(ogg) err = %v, want ErrUnsupportedFormat", err)
+	}
+	if err := player.Play(strings.NewReader("")); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("Play(empty) err = %v, want ErrUnsupportedFormat", err)
+	}
+
+	if got := registry.Formats(); !reflect.DeepEqual(got, []AudioFormat{AudioFormatFLAC}) {
+		t.Fatalf("Formats() = %v", got)
+	}
+	if !registry.Unregister(AudioFormatFLAC) || registry.Unregister(AudioFormatFLAC) {
+		t.Fatal("Unregister should succeed exactly once")
+	}
+	if want := []AudioFormat{"mp3", "mp4", "wav"}; !reflect.DeepEqual(Codecs(), want) {
+		t.Fatalf("Codecs() = %v, want %v", Codecs(), want)
+	}
+}
+
+func TestAudioPlayerFile(t *testing.T) {
+	recorder := recordOutput(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "song.mp4")
+	if err := os.WriteFile(path, mp4Header, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	player := NewAudioPlayer()
+	if err := player.PlayFile(path); err != nil {
+		t.Fatal(err)
+	}
+	assertMessages(t, recorder, "Playing mp4 file")
+
+	if err := player.PlayFile(filepath.Join(dir, "missing.mp3")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("PlayFile(missing) err = %v, want ErrNotExist", err)
+	}
+	text := filepath.Join(dir, "notes.mp3")
+	if err := os.WriteFile(text, []byte("not really an mp3"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := player.PlayFile(text)
+	if !errors.Is(err, ErrUnsupportedFormat) || !strings.Contains(err.Error(), text) {
+		t.Fatalf("PlayFile(text) err = %v, want ErrUnsupportedFormat with the path", err)
+	}
+}
+
+func TestAdvancedPlayersRejectOtherFormats(t *testing.T) {
+	if err := NewMp4Adapter(&VlcPlayer{}).Play(bytes.NewReader(mp4Header)); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("VlcPlayer.PlayMp4 err = %v, want ErrUnsupportedFormat", err)
+	}
+	if err := NewVlcAdapter(&Mp4Player{}).Play(bytes.NewReader(oggHeader)); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("Mp4Player.PlayVlc err = %v, want ErrUnsupportedFormat", err)
+	}
 }
@@ -1,12 +1,23 @@
 package designpattern
 
 import (
-	"fmt"
 	"testing"
 )
 
 func TestProxy(t *testing.T) {
 	proxy := NewProxy()
 	result := proxy.Do()
-	fmt.Println(result)
+	if want := "Proxy: RealSubject: doing something"; result != want {
+		t.Fatalf("Do() = %q, want %q", result, want)
+	}
+}
+
+func TestImageProxy(t *testing.T) {
+	proxy := NewImageProxy("photo.png")
+	if got := proxy.Display(); got != "Displaying photo.png" {
+		t.Fatalf("first Display() = %q", got)
+	}
+	if got := proxy.Display(); got != "Cached: Displaying photo.png" {
+		t.Fatalf("second Display() = %q", got)
+	}
 }
@@ -12,7 +12,7 @@ type Character struct {
 }
 
 func (c *Character) Print() {
-	fmt.Printf("Printing character %c with font %s, size %d, bold %v, italic %v\n", c.char, c.font, c.size, c.isBold, c.isItalic)
+	emitf("Character", "Printing character %c with font %s, size %d, bold %v, italic %v", c.char, c.font, c.size, c.isBold, c.isItalic)
 }
 
 type CharacterFactory struct {
@@ -1,16 +1,21 @@
 package designpattern
 
 import (
-	"fmt"
 	"testing"
 )
 
 func TestFlyweight(t *testing.T) {
+	recorder := recordOutput(t)
 	charFactory := NewCharacterFactory()
 
 	char1 := charFactory.GetCharacter('A', "Arial", 12, true, false)
 	char2 := charFactory.GetCharacter('A', "Arial", 12, true, false)
 
 	// char1 和 char2 是同一个对象的引用
-	fmt.Println(char1 == char2) // true
+	if char1 != char2 {
+		t.Fatal("GetCharacter returned different instances for the same key")
+	}
+
+	char1.Print()
+	assertMessages(t, recorder, "Printing character A with font Arial, size 12, bold true, italic false")
 }
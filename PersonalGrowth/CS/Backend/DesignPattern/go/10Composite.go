@@ -1,7 +1,5 @@
 package designpattern
 
-import "fmt"
-
 type FileSystemNode interface {
 	GetName() string
 	GetSize() int
@@ -22,7 +20,7 @@ func (f *File) GetName() string   { return f.name }
 func (f *File) GetSize() int      { return f.size }
 func (f *File) IsDirectory() bool { return false }
 func (f *File) Print(prefix string) {
-	fmt.Printf("%s- %s (%d bytes)\n", prefix, f.name, f.size)
+	emitf("File", "%s- %s (%d bytes)", prefix, f.name, f.size)
 }
 
 type Directory struct {
@@ -53,7 +51,7 @@ func (d *Directory) GetSize() int {
 }
 
 func (d *Directory) Print(prefix string) {
-	fmt.Printf("%s+ %s\n", prefix, d.name)
+	emitf("Directory", "%s+ %s", prefix, d.name)
 	for _, child := range d.children {
 		child.Print(prefix + "  ")
 	}
@@ -3,9 +3,20 @@ package designpattern
 import "testing"
 
 func TestComposite(t *testing.T) {
+	recorder := recordOutput(t)
 	root := NewDirectory("root")
 	root.Add(NewFile("file1.txt", 100))
 	root.Add(NewFile("file2.txt", 200))
 	root.Add(NewDirectory("subdir"))
 	root.Print("")
+
+	assertMessages(t, recorder,
+		"+ root",
+		"  - file1.txt (100 bytes)",
+		"  - file2.txt (200 bytes)",
+		"  + subdir",
+	)
+	if got := root.GetSize(); got != 300 {
+		t.Fatalf("GetSize() = %d, want 300", got)
+	}
 }
@@ -1,7 +1,6 @@
 package designpattern
 
 import (
-	"log"
 	"net/http"
 	"time"
 )
@@ -22,7 +21,7 @@ func Logger(next http.Handler) http.Handler {
 		now := time.Now()
 		// 执行被装饰的handler
 		next.ServeHTTP(w, req)
-		log.Printf("spend time: %v", time.Since(now))
+		emitf("Logger", "spend time: %v", time.Since(now))
 	}
 	return http.HandlerFunc(fn)
 }
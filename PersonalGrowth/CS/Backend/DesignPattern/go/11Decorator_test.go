@@ -1,23 +1,39 @@
 package designpattern
 
 import (
-	"fmt"
+	"math"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
 func TestDecorator(t *testing.T) {
+	recorder := recordOutput(t)
 	mux := http.NewServeMux()
-	mux.HandleFunc("GET /hello", HelloWorld)
-	mux.HandleFunc("GET /how", HowAreYou)
+	mux.HandleFunc("/hello", HelloWorld)
+	mux.HandleFunc("/how", HowAreYou)
 
-	srv := http.Server{
-		Addr: ":8080",
-		// 执行装饰器
-		Handler: Logger(mux),
+	// 执行装饰器
+	handler := Logger(mux)
+
+	for path, want := range map[string]string{"/hello": "hello world", "/how": "how are you"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK || rec.Body.String() != want {
+			t.Fatalf("GET %s = %d %q, want 200 %q", path, rec.Code, rec.Body.String(), want)
+		}
 	}
 
-	srv.ListenAndServe()
+	events := recorder.Events()
+	if len(events) != 2 {
+		t.Fatalf("events = %v, want one per request", events)
+	}
+	for _, event := range events {
+		if event.Source != "Logger" || !strings.HasPrefix(event.Message, "spend time: ") {
+			t.Fatalf("unexpected event %v", event)
+		}
+	}
 }
 
 func TestCoffee(t *testing.T) {
@@ -26,6 +42,10 @@ func TestCoffee(t *testing.T) {
 	coffeeWithMilk := NewMilkDecorator(coffee)
 	coffeeWithMilkAndSugar := NewSugarDecorator(coffeeWithMilk)
 
-	fmt.Printf("Cost: %.2f\n", coffeeWithMilkAndSugar.Cost())
-	fmt.Println("Description:", coffeeWithMilkAndSugar.Description())
+	if got := coffeeWithMilkAndSugar.Cost(); math.Abs(got-1.7) > 1e-9 {
+		t.Fatalf("Cost() = %.2f, want 1.70", got)
+	}
+	if got, want := coffeeWithMilkAndSugar.Description(), "Simple coffee, milk, sugar"; got != want {
+		t.Fatalf("Description() = %q, want %q", got, want)
+	}
 }
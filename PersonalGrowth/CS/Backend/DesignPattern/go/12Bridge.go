@@ -1,7 +1,5 @@
 package designpattern
 
-import "fmt"
-
 type DrawAPI interface {
 	DrawCircle(x, y, radius int)
 }
@@ -9,13 +7,13 @@ type DrawAPI interface {
 type DrawingAPI1 struct{}
 
 func (d *DrawingAPI1) DrawCircle(x, y, radius int) {
-	fmt.Printf("API1.circle at %d:%d radius %d\n", x, y, radius)
+	emitf("DrawingAPI1", "API1.circle at %d:%d radius %d", x, y, radius)
 }
 
 type DrawingAPI2 struct{}
 
 func (d *DrawingAPI2) DrawCircle(x, y, radius int) {
-	fmt.Printf("API2.circle at %d:%d radius %d\n", x, y, radius)
+	emitf("DrawingAPI2", "API2.circle at %d:%d radius %d", x, y, radius)
 }
 
 type CircleShape struct {
@@ -3,6 +3,7 @@ package designpattern
 import "testing"
 
 func TestBridge(t *testing.T) {
+	recorder := recordOutput(t)
 	api1 := &DrawingAPI1{}
 	api2 := &DrawingAPI2{}
 
@@ -11,4 +12,6 @@ func TestBridge(t *testing.T) {
 
 	circle1.Draw()
 	circle2.Draw()
+
+	assertMessages(t, recorder, "API1.circle at 1:2 radius 3", "API2.circle at 5:7 radius 11")
 }
@@ -1,10 +1,18 @@
 package designpattern
 
 import (
-	"fmt"
+	"reflect"
 	"testing"
 )
 
+func personNames(persons []*Person) []string {
+	names := make([]string, 0, len(persons))
+	for _, person := range persons {
+		names = append(names, person.name)
+	}
+	return names
+}
+
 func TestFilter(t *testing.T) {
 	persons := []*Person{
 		NewPerson("John", "Male", 25, 3000),
@@ -20,27 +28,19 @@ func TestFilter(t *testing.T) {
 	highSalary := NewSalaryCriteria(4000)
 
 	// 查找所有男性
-	malePersons := male.MeetCriteria(persons)
-	fmt.Println("Males:")
-	for _, person := range malePersons {
-		fmt.Printf("Name: %s, Gender: %s\n", person.name, person.gender)
+	if got, want := personNames(male.MeetCriteria(persons)), []string{"John", "Bob", "Mike"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("males = %v, want %v", got, want)
 	}
 
 	// 查找年龄在25-35之间且薪资高于4000的人
 	ageAndSalary := NewAndCriteria(ageRange, highSalary)
-	result := ageAndSalary.MeetCriteria(persons)
-	fmt.Println("\nAge between 25-35 and high salary:")
-	for _, person := range result {
-		fmt.Printf("Name: %s, Age: %d, Salary: %.2f\n",
-			person.name, person.age, person.salary)
+	if got, want := personNames(ageAndSalary.MeetCriteria(persons)), []string{"Alice", "Bob"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("age between 25-35 and high salary = %v, want %v", got, want)
 	}
 
 	// 查找女性或高薪资的人
 	femaleOrHighSalary := NewOrCriteria(female, highSalary)
-	result = femaleOrHighSalary.MeetCriteria(persons)
-	fmt.Println("\nFemales or high salary:")
-	for _, person := range result {
-		fmt.Printf("Name: %s, Gender: %s, Salary: %.2f\n",
-			person.name, person.gender, person.salary)
+	if got, want := personNames(femaleOrHighSalary.MeetCriteria(persons)), []string{"Alice", "Emma", "Bob"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("females or high salary = %v, want %v", got, want)
 	}
 }
@@ -54,12 +54,12 @@ func NewConcreteColleague(name string, mediator Mediator) *ConcreteColleague {
 }
 
 func (c *ConcreteColleague) Send(message string) {
-	fmt.Printf("%s sends: %s\n", c.name, message)
+	emitf("ConcreteColleague", "%s sends: %s", c.name, message)
 	c.mediator.Send(message, c)
 }
 
 func (c *ConcreteColleague) Receive(message string) {
-	fmt.Printf("%s receives: %s\n", c.name, message)
+	emitf("ConcreteColleague", "%s receives: %s", c.name, message)
 }
 
 // 实际应用示例：聊天室系统
@@ -100,10 +100,10 @@ func NewUser(name string, chatRoom *ChatRoom) *User {
 }
 
 func (u *User) Send(message string) {
-	fmt.Printf("%s sends: %s\n", u.name, message)
+	emitf("User", "%s sends: %s", u.name, message)
 	u.chatRoom.Send(message, u)
 }
 
 func (u *User) Receive(message string) {
-	fmt.Printf("%s receives: %s\n", u.name, message)
+	emitf("User", "%s receives: %s", u.name, message)
 }
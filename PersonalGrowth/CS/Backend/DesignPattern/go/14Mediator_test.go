@@ -1,9 +1,13 @@
 package designpattern
 
-import "testing"
+import (
+	"sort"
+	"testing"
+)
 
 // 基本示例
 func TestMediator(t *testing.T) {
+	recorder := recordOutput(t)
 	mediator := NewConcreteMediator()
 
 	colleague1 := NewConcreteColleague("Colleague1", mediator)
@@ -11,10 +15,17 @@ func TestMediator(t *testing.T) {
 	_ = NewConcreteColleague("Colleague3", mediator)
 
 	colleague1.Send("Hello from Colleague1, I am Colleague1.")
+
+	assertMessages(t, recorder,
+		"Colleague1 sends: Hello from Colleague1, I am Colleague1.",
+		"Colleague2 receives: Hello from Colleague1, I am Colleague1.",
+		"Colleague3 receives: Hello from Colleague1, I am Colleague1.",
+	)
 }
 
 // 聊天室示例
 func TestChatRoom(t *testing.T) {
+	recorder := recordOutput(t)
 	chatRoom := NewChatRoom()
 
 	alice := NewUser("Alice", chatRoom)
@@ -22,4 +33,16 @@ func TestChatRoom(t *testing.T) {
 	_ = NewUser("Charlie", chatRoom)
 
 	alice.Send("Hi everyone!")
+
+	// 聊天室用 map 保存用户，接收顺序不固定
+	messages := recorder.Messages()
+	if len(messages) != 3 || messages[0] != "Alice sends: Hi everyone!" {
+		t.Fatalf("messages = %q", messages)
+	}
+	received := messages[1:]
+	sort.Strings(received)
+	want := []string{"Bob receives: From Alice: Hi everyone!", "Charlie receives: From Alice: Hi everyone!"}
+	if received[0] != want[0] || received[1] != want[1] {
+		t.Fatalf("received = %q, want %q", received, want)
+	}
 }
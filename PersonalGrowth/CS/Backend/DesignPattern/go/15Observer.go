@@ -1,7 +1,5 @@
 package designpattern
 
-import "fmt"
-
 // Observer 定义观察者接口
 type Observer interface {
 	Update(message string)
@@ -60,7 +58,7 @@ func NewConcreteObserver(name string) *ConcreteObserver {
 }
 
 func (o *ConcreteObserver) Update(message string) {
-	fmt.Printf("Observer %s received: %s\n", o.name, message)
+	emitf("ConcreteObserver", "Observer %s received: %s", o.name, message)
 }
 
 // 实际应用示例：新闻订阅系统
@@ -108,7 +106,7 @@ func NewEmailSubscriber(email string) *EmailSubscriber {
 }
 
 func (s *EmailSubscriber) ReceiveNews(news string) {
-	fmt.Printf("Sending news to %s: %s\n", s.email, news)
+	emitf("EmailSubscriber", "Sending news to %s: %s", s.email, news)
 }
 
 type SMSSubscriber struct {
@@ -120,5 +118,5 @@ func NewSMSSubscriber(phoneNumber string) *SMSSubscriber {
 }
 
 func (s *SMSSubscriber) ReceiveNews(news string) {
-	fmt.Printf("Sending SMS to %s: %s\n", s.phoneNumber, news)
+	emitf("SMSSubscriber", "Sending SMS to %s: %s", s.phoneNumber, news)
 }
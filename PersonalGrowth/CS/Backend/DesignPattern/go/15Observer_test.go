@@ -5,6 +5,7 @@ import (
 )
 
 func TestObserver(t *testing.T) {
+	recorder := recordOutput(t)
 	// 基本示例
 	subject := NewConcreteSubject()
 
@@ -15,9 +16,19 @@ func TestObserver(t *testing.T) {
 	subject.Attach(observer2)
 
 	subject.SetState("New State!")
+
+	subject.Detach(observer1)
+	subject.SetState("Another State!")
+
+	assertMessages(t, recorder,
+		"Observer Observer 1 received: New State!",
+		"Observer Observer 2 received: New State!",
+		"Observer Observer 2 received: Another State!",
+	)
 }
 
 func TestNewsAgency(t *testing.T) {
+	recorder := recordOutput(t)
 	// 新闻订阅示例
 	newsAgency := NewNewsAgency()
 
@@ -28,4 +39,9 @@ func TestNewsAgency(t *testing.T) {
 	newsAgency.Subscribe(smsSub)
 
 	newsAgency.PublishNews("Breaking News: Go 2.0 Released!")
+
+	assertMessages(t, recorder,
+		"Sending news to user@example.com: Breaking News: Go 2.0 Released!",
+		"Sending SMS to +1234567890: Breaking News: Go 2.0 Released!",
+	)
 }
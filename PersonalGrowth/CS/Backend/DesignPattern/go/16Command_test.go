@@ -1,7 +1,7 @@
 package designpattern
 
 import (
-	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -15,11 +15,16 @@ func TestCommand(t *testing.T) {
 	invoker.AddCommand(command)
 
 	results := invoker.ExecuteCommands()
-	fmt.Println(results)
-
-	undoResult := invoker.UndoLastCommand()
-	fmt.Println(undoResult)
-
+	if want := []string{"Receiver Main is handling the request"}; !reflect.DeepEqual(results, want) {
+		t.Fatalf("ExecuteCommands() = %q, want %q", results, want)
+	}
+
+	if got, want := invoker.UndoLastCommand(), "Receiver Main is undoing the request"; got != want {
+		t.Fatalf("UndoLastCommand() = %q, want %q", got, want)
+	}
+	if got, want := invoker.UndoLastCommand(), "No commands to undo"; got != want {
+		t.Fatalf("UndoLastCommand() = %q, want %q", got, want)
+	}
 }
 
 func TestTextEditor(t *testing.T) {
@@ -27,21 +32,28 @@ func TestTextEditor(t *testing.T) {
 	// 文本编辑器示例
 	editor := NewTextEditor()
 
+	assertContent := func(want string) {
+		t.Helper()
+		if got := editor.GetContent(); got != want {
+			t.Fatalf("content = %q, want %q", got, want)
+		}
+	}
+
 	insertCmd := NewInsertCommand(editor, "Hello", 0)
 	invoker.AddCommand(insertCmd)
 	invoker.ExecuteCommands()
-	fmt.Println("Content:", editor.GetContent())
+	assertContent("Hello")
 
 	insertCmd2 := NewInsertCommand(editor, " World", 5)
 	invoker.AddCommand(insertCmd2)
 	invoker.ExecuteCommands()
-	fmt.Println("Content:", editor.GetContent())
+	assertContent("Hello World")
 
 	deleteCmd := NewDeleteCommand(editor, 5, 6)
 	invoker.AddCommand(deleteCmd)
 	invoker.ExecuteCommands()
-	fmt.Println("Content:", editor.GetContent())
+	assertContent("Hello")
 
 	invoker.UndoLastCommand()
-	fmt.Println("Content after undo:", editor.GetContent())
+	assertContent("Hello World")
 }
@@ -1,7 +1,7 @@
 package designpattern
 
 import (
-	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -12,11 +12,17 @@ func TestIterator(t *testing.T) {
 	container.Add("Item 2")
 	container.Add("Item 3")
 
+	var items []interface{}
 	iterator := container.CreateIterator()
 	for iterator.HasNext() {
-		fmt.Println(iterator.Next())
+		items = append(items, iterator.Next())
+	}
+	if want := []interface{}{"Item 1", "Item 2", "Item 3"}; !reflect.DeepEqual(items, want) {
+		t.Fatalf("items = %v, want %v", items, want)
+	}
+	if iterator.Next() != nil || iterator.Current() != nil {
+		t.Fatal("exhausted iterator returned an item")
 	}
-
 }
 
 func TestFileSystemIterator(t *testing.T) {
@@ -30,10 +36,14 @@ func TestFileSystemIterator(t *testing.T) {
 	docs.Add(file1)
 	docs.Add(file2)
 
+	var names []string
 	iterator := NewFileSystemIterator(root.children)
 	for iterator.HasNext() {
 		if item, ok := iterator.Next().(FileSystemItem); ok {
-			fmt.Println(item.GetName())
+			names = append(names, item.GetName())
 		}
 	}
+	if want := []string{"docs"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("names = %v, want %v", names, want)
+	}
 }
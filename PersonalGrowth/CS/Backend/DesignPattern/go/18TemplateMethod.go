@@ -1,7 +1,5 @@
 package designpattern
 
-import "fmt"
-
 // AbstractClass 定义抽象类
 type AbstractClass interface {
 	TemplateMethod()
@@ -39,11 +37,11 @@ func NewConcreteClassA() *ConcreteClassA {
 }
 
 func (c *ConcreteClassA) PrimitiveOperation1() {
-	fmt.Println("ConcreteClassA: PrimitiveOperation1")
+	emit("ConcreteClassA", "ConcreteClassA: PrimitiveOperation1")
 }
 
 func (c *ConcreteClassA) PrimitiveOperation2() {
-	fmt.Println("ConcreteClassA: PrimitiveOperation2")
+	emit("ConcreteClassA", "ConcreteClassA: PrimitiveOperation2")
 }
 
 // ConcreteClassB 具体实现B
@@ -58,15 +56,15 @@ func NewConcreteClassB() *ConcreteClassB {
 }
 
 func (c *ConcreteClassB) PrimitiveOperation1() {
-	fmt.Println("ConcreteClassB: PrimitiveOperation1")
+	emit("ConcreteClassB", "ConcreteClassB: PrimitiveOperation1")
 }
 
 func (c *ConcreteClassB) PrimitiveOperation2() {
-	fmt.Println("ConcreteClassB: PrimitiveOperation2")
+	emit("ConcreteClassB", "ConcreteClassB: PrimitiveOperation2")
 }
 
 func (c *ConcreteClassB) Hook() {
-	fmt.Println("ConcreteClassB: Hook")
+	emit("ConcreteClassB", "ConcreteClassB: Hook")
 }
 
 // 实际应用示例：数据处理框架
@@ -106,27 +104,27 @@ func NewPDFMiner() *PDFMiner {
 }
 
 func (p *PDFMiner) OpenFile() {
-	fmt.Println("Opening PDF file")
+	emit("PDFMiner", "Opening PDF file")
 }
 
 func (p *PDFMiner) ExtractData() {
-	fmt.Println("Extracting data from PDF")
+	emit("PDFMiner", "Extracting data from PDF")
 }
 
 func (p *PDFMiner) ParseData() {
-	fmt.Println("Parsing PDF data")
+	emit("PDFMiner", "Parsing PDF data")
 }
 
 func (p *PDFMiner) AnalyzeData() {
-	fmt.Println("Analyzing PDF data")
+	emit("PDFMiner", "Analyzing PDF data")
 }
 
 func (p *PDFMiner) SendReport() {
-	fmt.Println("Sending PDF report")
+	emit("PDFMiner", "Sending PDF report")
 }
 
 func (p *PDFMiner) CloseFile() {
-	fmt.Println("Closing PDF file")
+	emit("PDFMiner", "Closing PDF file")
 }
 
 // CSVMiner CSV文件处理器
@@ -141,25 +139,25 @@ func NewCSVMiner() *CSVMiner {
 }
 
 func (c *CSVMiner) OpenFile() {
-	fmt.Println("Opening CSV file")
+	emit("CSVMiner", "Opening CSV file")
 }
 
 func (c *CSVMiner) ExtractData() {
-	fmt.Println("Extracting data from CSV")
+	emit("CSVMiner", "Extracting data from CSV")
 }
 
 func (c *CSVMiner) ParseData() {
-	fmt.Println("Parsing CSV data")
+	emit("CSVMiner", "Parsing CSV data")
 }
 
 func (c *CSVMiner) AnalyzeData() {
-	fmt.Println("Analyzing CSV data")
+	emit("CSVMiner", "Analyzing CSV data")
 }
 
 func (c *CSVMiner) SendReport() {
-	fmt.Println("Sending CSV report")
+	emit("CSVMiner", "Sending CSV report")
 }
 
 func (c *CSVMiner) CloseFile() {
-	fmt.Println("Closing CSV file")
+	emit("CSVMiner", "Closing CSV file")
 }
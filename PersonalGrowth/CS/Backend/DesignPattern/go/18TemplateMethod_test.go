@@ -5,20 +5,49 @@ import (
 )
 
 func TestTemplateMethod(t *testing.T) {
+	recorder := recordOutput(t)
 	// 基本示例
 	classA := NewConcreteClassA()
 	classA.TemplateMethod()
 
 	classB := NewConcreteClassB()
 	classB.TemplateMethod()
+
+	assertMessages(t, recorder,
+		"ConcreteClassA: PrimitiveOperation1",
+		"ConcreteClassA: PrimitiveOperation2",
+		"ConcreteClassB: PrimitiveOperation1",
+		"ConcreteClassB: PrimitiveOperation2",
+		"ConcreteClassB: Hook",
+	)
 }
 
 func TestPDFMiner(t *testing.T) {
+	recorder := recordOutput(t)
 	pdfMiner := NewPDFMiner()
 	pdfMiner.Mine()
+
+	assertMessages(t, recorder,
+		"Opening PDF file",
+		"Extracting data from PDF",
+		"Parsing PDF data",
+		"Analyzing PDF data",
+		"Sending PDF report",
+		"Closing PDF file",
+	)
 }
 
 func TestCSVMiner(t *testing.T) {
+	recorder := recordOutput(t)
 	csvMiner := NewCSVMiner()
 	csvMiner.Mine()
+
+	assertMessages(t, recorder,
+		"Opening CSV file",
+		"Extracting data from CSV",
+		"Parsing CSV data",
+		"Analyzing CSV data",
+		"Sending CSV report",
+		"Closing CSV file",
+	)
 }
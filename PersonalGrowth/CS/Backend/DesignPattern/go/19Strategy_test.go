@@ -1,19 +1,20 @@
 package designpattern
 
 import (
-	"fmt"
 	"testing"
 )
 
 func TestStrategy(t *testing.T) {
 	// 基本示例
 	context := NewContext(&ConcreteStrategyA{})
-	result := context.ExecuteStrategy("data")
-	fmt.Println("result1:", result)
+	if got, want := context.ExecuteStrategy("data"), "Executing strategy A with data"; got != want {
+		t.Fatalf("result1 = %v, want %q", got, want)
+	}
 
 	context.SetStrategy(&ConcreteStrategyB{})
-	result = context.ExecuteStrategy("data")
-	fmt.Println("result2:", result)
+	if got, want := context.ExecuteStrategy("data"), "Executing strategy B with data"; got != want {
+		t.Fatalf("result2 = %v, want %q", got, want)
+	}
 }
 
 func TestPayment(t *testing.T) {
@@ -23,11 +24,17 @@ func TestPayment(t *testing.T) {
 	alipay := NewAlipayPayment("user123")
 
 	payment := NewPaymentContext(creditCard)
-	fmt.Println(payment.ProcessPayment(100.50))
+	if got, want := payment.ProcessPayment(100.50), "Paid 100.50 using Credit Card 1234-5678-9012-3456"; got != want {
+		t.Fatalf("credit card = %q, want %q", got, want)
+	}
 
 	payment.SetStrategy(paypal)
-	fmt.Println(payment.ProcessPayment(50.75))
+	if got, want := payment.ProcessPayment(50.75), "Paid 50.75 using PayPal account user@example.com"; got != want {
+		t.Fatalf("paypal = %q, want %q", got, want)
+	}
 
 	payment.SetStrategy(alipay)
-	fmt.Println(payment.ProcessPayment(200.00))
+	if got, want := payment.ProcessPayment(200.00), "Paid 200.00 using Alipay account user123"; got != want {
+		t.Fatalf("alipay = %q, want %q", got, want)
+	}
 }
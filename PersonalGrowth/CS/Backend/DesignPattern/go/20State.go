@@ -1,7 +1,5 @@
 package designpattern
 
-import "fmt"
-
 // 实际应用示例：订单状态系统
 type OrderState interface {
 	Handle(order *Order)
@@ -32,7 +30,7 @@ func (o *Order) GetStateName() string {
 type NewOrderState struct{}
 
 func (s *NewOrderState) Handle(order *Order) {
-	fmt.Println("Processing new order, transitioning to paid")
+	emit("NewOrderState", "Processing new order, transitioning to paid")
 	order.SetState(&PaidOrderState{})
 }
 
@@ -44,7 +42,7 @@ func (s *NewOrderState) GetName() string {
 type PaidOrderState struct{}
 
 func (s *PaidOrderState) Handle(order *Order) {
-	fmt.Println("Processing paid order, transitioning to shipped")
+	emit("PaidOrderState", "Processing paid order, transitioning to shipped")
 	order.SetState(&ShippedOrderState{})
 }
 
@@ -56,7 +54,7 @@ func (s *PaidOrderState) GetName() string {
 type ShippedOrderState struct{}
 
 func (s *ShippedOrderState) Handle(order *Order) {
-	fmt.Println("Processing shipped order, transitioning to delivered")
+	emit("ShippedOrderState", "Processing shipped order, transitioning to delivered")
 	order.SetState(&DeliveredOrderState{})
 }
 
@@ -68,7 +66,7 @@ func (s *ShippedOrderState) GetName() string {
 type DeliveredOrderState struct{}
 
 func (s *DeliveredOrderState) Handle(order *Order) {
-	fmt.Println("Order has been delivered, no further transitions")
+	emit("DeliveredOrderState", "Order has been delivered, no further transitions")
 }
 
 func (s *DeliveredOrderState) GetName() string {
@@ -1,21 +1,37 @@
 package designpattern
 
 import (
-	"fmt"
 	"testing"
 )
 
 func TestOrderState(t *testing.T) {
+	recorder := recordOutput(t)
 	// 订单状态示例
 	order := NewOrder()
-	fmt.Println("Order state:", order.GetStateName())
+	states := []string{order.GetStateName()}
 
 	order.Process() // New -> Paid
-	fmt.Println("Order state:", order.GetStateName())
+	states = append(states, order.GetStateName())
 
 	order.Process() // Paid -> Shipped
-	fmt.Println("Order state:", order.GetStateName())
+	states = append(states, order.GetStateName())
 
 	order.Process() // Shipped -> Delivered
-	fmt.Println("Order state:", order.GetStateName())
+	states = append(states, order.GetStateName())
+
+	order.Process() // Delivered 之后不再变化
+	states = append(states, order.GetStateName())
+
+	want := []string{"New Order", "Paid", "Shipped", "Delivered", "Delivered"}
+	for i := range want {
+		if states[i] != want[i] {
+			t.Fatalf("states = %q, want %q", states, want)
+		}
+	}
+	assertMessages(t, recorder,
+		"Processing new order, transitioning to paid",
+		"Processing paid order, transitioning to shipped",
+		"Processing shipped order, transitioning to delivered",
+		"Order has been delivered, no further transitions",
+	)
 }
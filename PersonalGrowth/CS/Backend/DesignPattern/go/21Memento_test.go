@@ -1,7 +1,6 @@
 package designpattern
 
 import (
-	"fmt"
 	"testing"
 )
 
@@ -17,23 +16,37 @@ func TestMemento(t *testing.T) {
 	caretaker.AddMemento(originator.SaveToMemento())
 
 	originator.SetState("State 3")
-	fmt.Println("Current State:", originator.GetState())
+	if got := originator.GetState(); got != "State 3" {
+		t.Fatalf("current state = %q, want State 3", got)
+	}
 
 	originator.RestoreFromMemento(caretaker.GetMemento(1))
-	fmt.Println("Restored to State:", originator.GetState())
-
+	if got := originator.GetState(); got != "State 2" {
+		t.Fatalf("restored state = %q, want State 2", got)
+	}
+	if caretaker.GetMemento(2) != nil {
+		t.Fatal("GetMemento(2) returned a memento")
+	}
 }
 
 func TestMementoTextEditor(t *testing.T) {
 	// 文本编辑器示例
 	editor := NewMementoTextEditor()
 
+	assertEditor := func(content string, cursor int) {
+		t.Helper()
+		if editor.GetContent() != content || editor.GetCursorPosition() != cursor {
+			t.Fatalf("editor = %q@%d, want %q@%d", editor.GetContent(), editor.GetCursorPosition(), content, cursor)
+		}
+	}
+
 	editor.Write("Hello")
-	fmt.Printf("Content: %s, Cursor: %d\n", editor.GetContent(), editor.GetCursorPosition())
+	assertEditor("Hello", 5)
 
 	editor.Write(" World")
-	fmt.Printf("Content: %s, Cursor: %d\n", editor.GetContent(), editor.GetCursorPosition())
+	assertEditor("Hello World", 11)
 
+	// 历史记录保存的是每次写入之前的状态，第一次撤销回到写入 "Hello" 之前
 	editor.Undo()
-	fmt.Printf("After undo - Content: %s, Cursor: %d\n", editor.GetContent(), editor.GetCursorPosition())
+	assertEditor("", 0)
 }
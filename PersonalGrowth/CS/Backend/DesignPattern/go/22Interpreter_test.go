@@ -1,7 +1,6 @@
 package designpattern
 
 import (
-	"fmt"
 	"testing"
 )
 
@@ -11,26 +10,29 @@ func TestInterpreter(t *testing.T) {
 	three := NewNumberExpression(3)
 	add := NewAddExpression(five, three)
 	two := NewNumberExpression(2)
-    subtract := NewSubtractExpression(add, two)
-    
-    result := subtract.Interpret()
-    fmt.Printf("(5 + 3) - 2 = %d\n", result)
-    
+	subtract := NewSubtractExpression(add, two)
+
+	if result := subtract.Interpret(); result != 6 {
+		t.Fatalf("(5 + 3) - 2 = %d, want 6", result)
+	}
 }
 
 func TestInterpreterBoolean(t *testing.T) {
-    // 布尔表达式示例: (true AND false) OR (true AND true)
-    trueExp := NewVariableExpression("true", true)
-    falseExp := NewVariableExpression("false", false)
-    
-    and1 := NewAndExpression(trueExp, falseExp)
-    and2 := NewAndExpression(trueExp, trueExp)
-    or := NewOrExpression(and1, and2)
-    
-    result := or.Interpret()
-    fmt.Printf("(true AND false) OR (true AND true) = %v\n", result)
-    
-    // NOT表达式示例
-    not := NewNotExpression(trueExp)
-	fmt.Printf("NOT true = %v\n", not.Interpret())
+	// 布尔表达式示例: (true AND false) OR (true AND true)
+	trueExp := NewVariableExpression("true", true)
+	falseExp := NewVariableExpression("false", false)
+
+	and1 := NewAndExpression(trueExp, falseExp)
+	and2 := NewAndExpression(trueExp, trueExp)
+	or := NewOrExpression(and1, and2)
+
+	if result := or.Interpret(); !result {
+		t.Fatalf("(true AND false) OR (true AND true) = %v, want true", result)
+	}
+
+	// NOT表达式示例
+	not := NewNotExpression(trueExp)
+	if result := not.Interpret(); result {
+		t.Fatalf("NOT true = %v, want false", result)
+	}
 }
@@ -1,7 +1,6 @@
 package designpattern
 
 import (
-	"fmt"
 	"testing"
 )
 
@@ -13,11 +12,16 @@ func TestChainOfResponsibility(t *testing.T) {
 
 	handlerA.SetNext(handlerB).SetNext(handlerC)
 
-	fmt.Println(handlerA.Handle("A"))
-	fmt.Println(handlerA.Handle("B"))
-	fmt.Println(handlerA.Handle("C"))
-	fmt.Println(handlerA.Handle("D"))
-
+	for request, want := range map[string]string{
+		"A": "Handler A handled A",
+		"B": "Handler B handled B",
+		"C": "Handler C handled C",
+		"D": "",
+	} {
+		if got := handlerA.Handle(request); got != want {
+			t.Errorf("Handle(%q) = %q, want %q", request, got, want)
+		}
+	}
 }
 
 func TestChainOfResponsibilityLeave(t *testing.T) {
@@ -29,13 +33,18 @@ func TestChainOfResponsibilityLeave(t *testing.T) {
 	teamLeader.SetNext(manager)
 	manager.SetNext(director)
 
-	request1 := NewLeaveRequest("John", 2, "Personal matters")
-	request2 := NewLeaveRequest("Alice", 5, "Family vacation")
-	request3 := NewLeaveRequest("Bob", 10, "Medical treatment")
-	request4 := NewLeaveRequest("Emma", 15, "Long vacation")
-
-	fmt.Println(teamLeader.HandleRequest(request1))
-	fmt.Println(teamLeader.HandleRequest(request2))
-	fmt.Println(teamLeader.HandleRequest(request3))
-	fmt.Println(teamLeader.HandleRequest(request4))
+	tests := []struct {
+		request *LeaveRequest
+		want    string
+	}{
+		{NewLeaveRequest("John", 2, "Personal matters"), "TeamLeader approved John's leave request for 2 days"},
+		{NewLeaveRequest("Alice", 5, "Family vacation"), "Manager approved Alice's leave request for 5 days"},
+		{NewLeaveRequest("Bob", 10, "Medical treatment"), "Director approved Bob's leave request for 10 days"},
+		{NewLeaveRequest("Emma", 15, "Long vacation"), "Leave request denied"},
+	}
+	for _, tt := range tests {
+		if got := teamLeader.HandleRequest(tt.request); got != tt.want {
+			t.Errorf("HandleRequest(%s) = %q, want %q", tt.request.name, got, tt.want)
+		}
+	}
 }
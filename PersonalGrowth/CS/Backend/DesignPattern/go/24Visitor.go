@@ -1,7 +1,5 @@
 package designpattern
 
-import "fmt"
-
 // Element 定义元素接口
 type Element interface {
 	Accept(visitor Visitor)
@@ -43,22 +41,22 @@ func (e *ConcreteElementB) Accept(visitor Visitor) {
 type ConcreteVisitor1 struct{}
 
 func (v *ConcreteVisitor1) VisitConcreteElementA(element *ConcreteElementA) {
-	fmt.Printf("Visitor1 visited ElementA: %s\n", element.name)
+	emitf("ConcreteVisitor1", "Visitor1 visited ElementA: %s", element.name)
 }
 
 func (v *ConcreteVisitor1) VisitConcreteElementB(element *ConcreteElementB) {
-	fmt.Printf("Visitor1 visited ElementB: %s\n", element.name)
+	emitf("ConcreteVisitor1", "Visitor1 visited ElementB: %s", element.name)
 }
 
 // ConcreteVisitor2 具体访问者2
 type ConcreteVisitor2 struct{}
 
 func (v *ConcreteVisitor2) VisitConcreteElementA(element *ConcreteElementA) {
-	fmt.Printf("Visitor2 visited ElementA: %s\n", element.name)
+	emitf("ConcreteVisitor2", "Visitor2 visited ElementA: %s", element.name)
 }
 
 func (v *ConcreteVisitor2) VisitConcreteElementB(element *ConcreteElementB) {
-	fmt.Printf("Visitor2 visited ElementB: %s\n", element.name)
+	emitf("ConcreteVisitor2", "Visitor2 visited ElementB: %s", element.name)
 }
 
 // ObjectStructure 对象结构
@@ -1,11 +1,11 @@
 package designpattern
 
 import (
-	"fmt"
 	"testing"
 )
 
 func TestVisitor(t *testing.T) {
+	recorder := recordOutput(t)
 	// 基本示例
 	objectStructure := &ObjectStructure{}
 	objectStructure.Attach(NewConcreteElementA("A1"))
@@ -17,6 +17,12 @@ func TestVisitor(t *testing.T) {
 	objectStructure.Accept(visitor1)
 	objectStructure.Accept(visitor2)
 
+	assertMessages(t, recorder,
+		"Visitor1 visited ElementA: A1",
+		"Visitor1 visited ElementB: B1",
+		"Visitor2 visited ElementA: A1",
+		"Visitor2 visited ElementB: B1",
+	)
 }
 
 func TestVisitorFile(t *testing.T) {
@@ -32,5 +38,7 @@ func TestVisitorFile(t *testing.T) {
 
 	sizeVisitor := &SizeVisitor{}
 	root.Accept(sizeVisitor)
-	fmt.Printf("Total size: %d bytes\n", sizeVisitor.GetTotalSize())
+	if got := sizeVisitor.GetTotalSize(); got != 300 {
+		t.Fatalf("total size = %d bytes, want 300", got)
+	}
 }
@@ -1,9 +1,6 @@
 package designpattern
 
-import (
-	"fmt"
-	"time"
-)
+import "time"
 
 // 函数选项模式
 // 简化了初始化结构体字段过多的问题，解决了创建结构体时自带默认值。
@@ -55,5 +52,5 @@ func NewClient(opts ...Option) *Client {
 
 func CreateClient() {
 	clt := NewClient(WithName("xan"))
-	fmt.Println("clent: ", clt)
+	emitf("Client", "client: %v", clt)
 }
@@ -1,12 +1,29 @@
 package designpattern
 
 import (
-	"fmt"
+	"strings"
 	"testing"
 	"time"
 )
 
 func TestFunctionOption(t *testing.T) {
 	client := NewClient(WithName("localhost"), WithTimeout(time.Duration(10)))
-	fmt.Println(client)
+	if client.Name != "localhost" || client.Timeout != 10 || client.ReadTime != 10 || client.WriteTime != 10 {
+		t.Fatalf("client = %+v", client)
+	}
+
+	client = NewClient(WithName("xan"), WithReadTime(time.Second), WithWriteTime(2*time.Second))
+	if client.Name != "xan" || client.ReadTime != time.Second || client.WriteTime != 2*time.Second {
+		t.Fatalf("client = %+v", client)
+	}
+}
+
+func TestCreateClient(t *testing.T) {
+	recorder := recordOutput(t)
+	CreateClient()
+
+	messages := recorder.Messages()
+	if len(messages) != 1 || !strings.HasPrefix(messages[0], "client: ") || !strings.Contains(messages[0], "xan") {
+		t.Fatalf("messages = %q", messages)
+	}
 }
@@ -0,0 +1,394 @@
+package designpattern
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// 依赖注入容器
+// 通过构造函数注册服务，构造函数的参数由容器自动解析。
+type Lifetime int
+
+const (
+	// LifetimeSingleton 整个容器只创建一次
+	LifetimeSingleton Lifetime = iota
+	// LifetimeTransient 每次解析都重新创建，实现了 Disposer 的实例在解析它的作用域结束时释放，
+	// 在根作用域中解析的实例在容器 Dispose 时释放
+	LifetimeTransient
+	// LifetimeScoped 每个作用域创建一次，作用域结束时释放
+	LifetimeScoped
+)
+
+func (l Lifetime) String() string {
+	switch l {
+	case LifetimeSingleton:
+		return "singleton"
+	case LifetimeTransient:
+		return "transient"
+	case LifetimeScoped:
+		return "scoped"
+	}
+	return fmt.Sprintf("Lifetime(%d)", int(l))
+}
+
+var (
+	ErrServiceNotRegistered = errors.New("service not registered")
+	ErrServiceRegistered    = errors.New("service already registered")
+	ErrDependencyCycle      = errors.New("dependency cycle")
+	ErrScopeRequired        = errors.New("scoped service resolved outside of a scope")
+	ErrScopeDisposed        = errors.New("scope already disposed")
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// Disposer 由需要在作用域或容器结束时释放资源的服务实现
+type Disposer interface {
+	Dispose() error
+}
+
+type serviceProvider struct {
+	typ         reflect.Type
+	constructor reflect.Value
+	params      []reflect.Type
+	returnsErr  bool
+	lifetime    Lifetime
+}
+
+// DIContainer 依赖注入容器，可并发使用。
+// mu 只保护注册表，构造函数和 Disposer 都在锁外执行，因此构造函数可以通过容器解析其它服务，
+// 但不能解析正在创建的服务本身。
+type DIContainer struct {
+	mu         sync.Mutex
+	providers  map[reflect.Type]*serviceProvider
+	acyclic    map[reflect.Type]bool // 已检查过没有循环依赖的类型，Provide 时清空
+	singletons *instanceStore
+}
+
+func NewDIContainer() *DIContainer {
+	return &DIContainer{
+		providers:  make(map[reflect.Type]*serviceProvider),
+		acyclic:    make(map[reflect.Type]bool),
+		singletons: newInstanceStore(),
+	}
+}
+
+// Provide 注册构造函数。构造函数形如 func(deps...) T 或 func(deps...) (T, error)，
+// 以返回值类型 T 作为服务的键。
+func (c *DIContainer) Provide(constructor interface{}, lifetime Lifetime) error {
+	fn := reflect.ValueOf(constructor)
+	if fn.Kind() != reflect.Func {
+		return fmt.Errorf("constructor must be a function, got %T", constructor)
+	}
+	fnType := fn.Type()
+	if fnType.IsVariadic() {
+		return fmt.Errorf("constructor %s must not be variadic", fnType)
+	}
+
+	provider := &serviceProvider{constructor: fn, lifetime: lifetime}
+	switch {
+	case fnType.NumOut() == 1:
+	case fnType.NumOut() == 2 && fnType.Out(1) == errorType:
+		provider.returnsErr = true
+	default:
+		return fmt.Errorf("constructor %s must return T or (T, error)", fnType)
+	}
+	provider.typ = fnType.Out(0)
+	for i := 0; i < fnType.NumIn(); i++ {
+		provider.params = append(provider.params, fnType.In(i))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.providers[provider.typ]; ok {
+		return fmt.Errorf("%w: %s", ErrServiceRegistered, provider.typ)
+	}
+	c.providers[provider.typ] = provider
+	c.acyclic = make(map[reflect.Type]bool)
+	return nil
+}
+
+// MustProvide 与 Provide 相同，出错时 panic，便于初始化时使用
+func (c *DIContainer) MustProvide(constructor interface{}, lifetime Lifetime) {
+	if err := c.Provide(constructor, lifetime); err != nil {
+		panic(err)
+	}
+}
+
+// Resolve 在根作用域中解析服务，target 必须是指向服务类型的指针
+func (c *DIContainer) Resolve(target interface{}) error {
+	return c.resolveInto(nil, target)
+}
+
+// NewScope 创建一个新的作用域
+func (c *DIContainer) NewScope() *DIScope {
+	return &DIScope{container: c, instances: newInstanceStore()}
+}
+
+// Dispose 按创建的逆序释放所有单例和在根作用域中创建的 transient 实例，之后容器仍然可用
+func (c *DIContainer) Dispose() error {
+	return c.singletons.dispose(false)
+}
+
+func (c *DIContainer) resolveInto(scope *DIScope, target interface{}) error {
+	ptr := reflect.ValueOf(target)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() {
+		return fmt.Errorf("resolve target must be a non-nil pointer, got %T", target)
+	}
+	if scope != nil && scope.instances.isDisposed() {
+		return ErrScopeDisposed
+	}
+
+	typ := ptr.Elem().Type()
+	if err := c.checkCycle(typ); err != nil {
+		return err
+	}
+	value, err := c.resolve(scope, typ, nil)
+	if err != nil {
+		return err
+	}
+	ptr.Elem().Set(value)
+	return nil
+}
+
+// checkCycle 沿注册表中的依赖关系检查 typ 是否处在循环依赖中。
+// 在创建实例之前检查，避免两个 goroutine 分别创建循环中的服务时互相等待。
+func (c *DIContainer) checkCycle(typ reflect.Type) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.walkDependencies(typ, nil)
+}
+
+// walkDependencies 调用时必须持有 c.mu，path 为当前的依赖链路
+func (c *DIContainer) walkDependencies(typ reflect.Type, path []reflect.Type) error {
+	if c.acyclic[typ] {
+		return nil
+	}
+	for i, t := range path {
+		if t == typ {
+			return fmt.Errorf("%w: %s", ErrDependencyCycle, formatTypePath(append(path[i:], typ)))
+		}
+	}
+	provider, ok := c.providers[typ]
+	if !ok {
+		// 缺少的服务由 resolve 报告
+		return nil
+	}
+	path = append(path, typ)
+	for _, param := range provider.params {
+		if err := c.walkDependencies(param, path); err != nil {
+			return err
+		}
+	}
+	c.acyclic[typ] = true
+	return nil
+}
+
+// resolve 创建或取出 typ 的实例，path 为当前的解析链路，用于报告缺少的依赖
+func (c *DIContainer) resolve(scope *DIScope, typ reflect.Type, path []reflect.Type) (reflect.Value, error) {
+	c.mu.Lock()
+	provider, ok := c.providers[typ]
+	c.mu.Unlock()
+	if !ok {
+		if len(path) == 0 {
+			return reflect.Value{}, fmt.Errorf("%w: %s", ErrServiceNotRegistered, typ)
+		}
+		return reflect.Value{}, fmt.Errorf("%w: %s (required by %s)", ErrServiceNotRegistered, typ, formatTypePath(path))
+	}
+
+	// transient 实例由解析它的作用域负责释放，根作用域中解析的由容器负责
+	store := c.singletons
+	switch provider.lifetime {
+	case LifetimeSingleton:
+		// 单例的依赖只能来自根作用域，避免捕获某个作用域内的实例
+		scope = nil
+	case LifetimeScoped:
+		if scope == nil {
+			return reflect.Value{}, fmt.Errorf("%w: %s", ErrScopeRequired, typ)
+		}
+		store = scope.instances
+	default:
+		if scope != nil {
+			store = scope.instances
+		}
+	}
+
+	path = append(path, typ)
+	create := func() (reflect.Value, error) {
+		args := make([]reflect.Value, len(provider.params))
+		for i, param := range provider.params {
+			arg, err := c.resolve(scope, param, path)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			args[i] = arg
+		}
+		out := provider.constructor.Call(args)
+		if provider.returnsErr && !out[1].IsNil() {
+			return reflect.Value{}, fmt.Errorf("construct %s: %w", typ, out[1].Interface().(error))
+		}
+		return out[0], nil
+	}
+	if provider.lifetime == LifetimeSingleton || provider.lifetime == LifetimeScoped {
+		return store.getOrCreate(typ, create)
+	}
+	value, err := create()
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return value, store.track(typ, value)
+}
+
+func formatTypePath(path []reflect.Type) string {
+	names := make([]string, len(path))
+	for i, t := range path {
+		names[i] = t.String()
+	}
+	return strings.Join(names, " -> ")
+}
+
+// DIScope 作用域，作用域内的 scoped 服务只创建一次
+type DIScope struct {
+	container *DIContainer
+	instances *instanceStore
+}
+
+// Resolve 在当前作用域中解析服务
+func (s *DIScope) Resolve(target interface{}) error {
+	return s.container.resolveInto(s, target)
+}
+
+// Dispose 按创建的逆序释放作用域内的 scoped 和 transient 实例，重复调用返回 ErrScopeDisposed
+func (s *DIScope) Dispose() error {
+	return s.instances.dispose(true)
+}
+
+// ServiceResolver 由 DIContainer 和 DIScope 实现
+type ServiceResolver interface {
+	Resolve(target interface{}) error
+}
+
+// ResolveService 是 Resolve 的泛型版本
+func ResolveService[T any](r ServiceResolver) (T, error) {
+	var service T
+	err := r.Resolve(&service)
+	return service, err
+}
+
+// instanceEntry 是一个正在创建或已经创建的实例，done 关闭后 value 和 err 才可读
+type instanceEntry struct {
+	done  chan struct{}
+	value reflect.Value
+	err   error
+}
+
+// instanceStore 保存已创建的实例，并按创建完成的顺序记录需要释放的实例
+type instanceStore struct {
+	mu       sync.Mutex
+	entries  map[reflect.Type]*instanceEntry
+	created  []createdInstance
+	disposed bool
+}
+
+type createdInstance struct {
+	typ      reflect.Type
+	disposer Disposer
+}
+
+func newInstanceStore() *instanceStore {
+	return &instanceStore{entries: make(map[reflect.Type]*instanceEntry)}
+}
+
+func (s *instanceStore) isDisposed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.disposed
+}
+
+// getOrCreate 返回 typ 的实例，同一类型同时只有一个 goroutine 调用 create，
+// 其它 goroutine 等待它的结果。创建失败时不保存，下一次解析重新创建。
+func (s *instanceStore) getOrCreate(typ reflect.Type, create func() (reflect.Value, error)) (reflect.Value, error) {
+	s.mu.Lock()
+	if s.disposed {
+		s.mu.Unlock()
+		return reflect.Value{}, ErrScopeDisposed
+	}
+	if entry, ok := s.entries[typ]; ok {
+		s.mu.Unlock()
+		<-entry.done
+		return entry.value, entry.err
+	}
+	entry := &instanceEntry{done: make(chan struct{})}
+	s.entries[typ] = entry
+	s.mu.Unlock()
+
+	// 构造函数 panic 时也要唤醒等待的 goroutine
+	entry.err = fmt.Errorf("construct %s: constructor panicked", typ)
+	defer func() {
+		if entry.err != nil {
+			s.mu.Lock()
+			if s.entries[typ] == entry {
+				delete(s.entries, typ)
+			}
+			s.mu.Unlock()
+		}
+		close(entry.done)
+	}()
+	value, err := create()
+	if err == nil {
+		err = s.track(typ, value)
+	}
+	if err != nil {
+		entry.err = err
+		return reflect.Value{}, err
+	}
+	entry.value, entry.err = value, nil
+	return value, nil
+}
+
+// track 记录需要释放的实例。作用域在实例创建期间被释放时立即释放该实例，并返回 ErrScopeDisposed。
+func (s *instanceStore) track(typ reflect.Type, value reflect.Value) error {
+	if !value.IsValid() || !value.CanInterface() {
+		return nil
+	}
+	disposer, ok := value.Interface().(Disposer)
+	if !ok {
+		return nil
+	}
+	s.mu.Lock()
+	if !s.disposed {
+		s.created = append(s.created, createdInstance{typ: typ, disposer: disposer})
+		s.mu.Unlock()
+		return nil
+	}
+	s.mu.Unlock()
+	if err := disposer.Dispose(); err != nil {
+		return fmt.Errorf("%w: dispose %s: %w", ErrScopeDisposed, typ, err)
+	}
+	return ErrScopeDisposed
+}
+
+// dispose 在锁外按创建的逆序调用 Disposer。final 为 true 时之后不能再创建实例，
+// 否则清空实例，之后重新创建。
+func (s *instanceStore) dispose(final bool) error {
+	s.mu.Lock()
+	if s.disposed {
+		s.mu.Unlock()
+		return ErrScopeDisposed
+	}
+	created := s.created
+	s.created = nil
+	s.entries = make(map[reflect.Type]*instanceEntry)
+	s.disposed = final
+	s.mu.Unlock()
+
+	var errs []error
+	for i := len(created) - 1; i >= 0; i-- {
+		if err := created[i].disposer.Dispose(); err != nil {
+			errs = append(errs, fmt.Errorf("dispose %s: %w", created[i].typ, err))
+		}
+	}
+	return errors.Join(errs...)
+}
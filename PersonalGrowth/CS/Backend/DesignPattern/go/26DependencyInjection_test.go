@@ -0,0 +1,259 @@
+package designpattern
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDIContainerFacade(t *testing.T) {
+	container := NewDIContainer()
+	container.MustProvide(func() *CPU { return &CPU{} }, LifetimeSingleton)
+	container.MustProvide(func() *Memory { return &Memory{} }, LifetimeSingleton)
+	container.MustProvide(func() *HardDrive { return &HardDrive{} }, LifetimeSingleton)
+	container.MustProvide(NewComputerFacadeWith, LifetimeTransient)
+
+	facade, err := ResolveService[*ComputerFacade](container)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpu, err := ResolveService[*CPU](container)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if facade.cpu != cpu {
+		t.Fatal("facade cpu is not the singleton CPU")
+	}
+
+	other, err := ResolveService[*ComputerFacade](container)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if facade == other {
+		t.Fatal("transient facade resolved twice to the same instance")
+	}
+	if err := facade.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := facade.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDIContainerFactoryInterface(t *testing.T) {
+	container := NewDIContainer()
+	container.MustProvide(func() (Factory, error) { return NewFactory(FactoryNameApple) }, LifetimeSingleton)
+
+	factory, err := ResolveService[Factory](container)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := factory.(*Apple); !ok {
+		t.Fatalf("factory = %T, want *Apple", factory)
+	}
+}
+
+type diDisposable struct {
+	name string
+	log  *[]string
+}
+
+func (d *diDisposable) Dispose() error {
+	*d.log = append(*d.log, d.name)
+	return nil
+}
+
+type diRepo struct{ *diDisposable }
+type diSession struct{ *diDisposable }
+
+func TestDIContainerScoped(t *testing.T) {
+	var disposed []string
+	container := NewDIContainer()
+	container.MustProvide(func() *diSession {
+		return &diSession{&diDisposable{name: "session", log: &disposed}}
+	}, LifetimeScoped)
+	container.MustProvide(func(s *diSession) *diRepo {
+		return &diRepo{&diDisposable{name: "repo", log: &disposed}}
+	}, LifetimeScoped)
+
+	if _, err := ResolveService[*diRepo](container); !errors.Is(err, ErrScopeRequired) {
+		t.Fatalf("root resolve err = %v, want ErrScopeRequired", err)
+	}
+
+	scope := container.NewScope()
+	repo1, err := ResolveService[*diRepo](scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo2, err := ResolveService[*diRepo](scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo1 != repo2 {
+		t.Fatal("scoped service resolved to different instances in one scope")
+	}
+
+	other := container.NewScope()
+	repo3, err := ResolveService[*diRepo](other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo1 == repo3 {
+		t.Fatal("scoped service shared between scopes")
+	}
+
+	if err := scope.Dispose(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"repo", "session"}; !reflect.DeepEqual(disposed, want) {
+		t.Fatalf("disposed = %v, want %v", disposed, want)
+	}
+	if err := scope.Dispose(); !errors.Is(err, ErrScopeDisposed) {
+		t.Fatalf("second Dispose err = %v, want ErrScopeDisposed", err)
+	}
+	if _, err := ResolveService[*diRepo](scope); !errors.Is(err, ErrScopeDisposed) {
+		t.Fatalf("resolve after dispose err = %v, want ErrScopeDisposed", err)
+	}
+}
+
+type diA struct{}
+type diB struct{}
+type diC struct{}
+
+func TestDIContainerCycle(t *testing.T) {
+	container := NewDIContainer()
+	container.MustProvide(func(*diB) *diA { return &diA{} }, LifetimeTransient)
+	container.MustProvide(func(*diC) *diB { return &diB{} }, LifetimeTransient)
+	container.MustProvide(func(*diA) *diC { return &diC{} }, LifetimeTransient)
+
+	_, err := ResolveService[*diA](container)
+	if !errors.Is(err, ErrDependencyCycle) {
+		t.Fatalf("err = %v, want ErrDependencyCycle", err)
+	}
+	want := "*designpattern.diA -> *designpattern.diB -> *designpattern.diC -> *designpattern.diA"
+	if !strings.Contains(err.Error(), want) {
+		t.Fatalf("err = %q, want path %q", err, want)
+	}
+}
+
+func TestDIContainerErrors(t *testing.T) {
+	container := NewDIContainer()
+	if err := container.Provide("not a func", LifetimeSingleton); err == nil {
+		t.Fatal("Provide(non-func) succeeded")
+	}
+	container.MustProvide(func(*diB) *diA { return &diA{} }, LifetimeSingleton)
+	if err := container.Provide(func() *diA { return &diA{} }, LifetimeSingleton); !errors.Is(err, ErrServiceRegistered) {
+		t.Fatalf("duplicate Provide err = %v, want ErrServiceRegistered", err)
+	}
+	if _, err := ResolveService[*diA](container); !errors.Is(err, ErrServiceNotRegistered) {
+		t.Fatalf("err = %v, want ErrServiceNotRegistered", err)
+	}
+
+	container.MustProvide(func() (*diC, error) { return nil, errors.New("boom") }, LifetimeSingleton)
+	if _, err := ResolveService[*diC](container); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("err = %v, want constructor error", err)
+	}
+}
+
+type diLazy struct{ get func() (*diA, error) }
+
+func TestDIContainerReentrant(t *testing.T) {
+	container := NewDIContainer()
+	container.MustProvide(func() *diA { return &diA{} }, LifetimeSingleton)
+	// 构造函数和 Disposer 都可以回到容器解析服务
+	container.MustProvide(func() *diLazy {
+		return &diLazy{get: func() (*diA, error) { return ResolveService[*diA](container) }}
+	}, LifetimeSingleton)
+	container.MustProvide(func() (*diB, error) {
+		lazy, err := ResolveService[*diLazy](container)
+		if err != nil {
+			return nil, err
+		}
+		_, err = lazy.get()
+		return &diB{}, err
+	}, LifetimeSingleton)
+
+	var log []string
+	container.MustProvide(func() *diSession {
+		return &diSession{&diDisposable{name: "session", log: &log}}
+	}, LifetimeTransient)
+	container.MustProvide(func(s *diSession) *diRepo {
+		return &diRepo{&diDisposable{name: "repo", log: &log}}
+	}, LifetimeScoped)
+
+	if _, err := ResolveService[*diB](container); err != nil {
+		t.Fatal(err)
+	}
+
+	scope := container.NewScope()
+	if _, err := ResolveService[*diRepo](scope); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ResolveService[*diSession](scope); err != nil {
+		t.Fatal(err)
+	}
+	// transient 实例也由作用域释放
+	if err := scope.Dispose(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"session", "repo", "session"}; !reflect.DeepEqual(log, want) {
+		t.Fatalf("disposed = %v, want %v", log, want)
+	}
+
+	container.MustProvide(func() *diC { return &diC{} }, LifetimeSingleton)
+	container.MustProvide(func(*diC) *diDisposable {
+		return &diDisposable{name: "root", log: &log}
+	}, LifetimeSingleton)
+	if _, err := ResolveService[*diDisposable](container); err != nil {
+		t.Fatal(err)
+	}
+	log = nil
+	if err := container.Dispose(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"root"}; !reflect.DeepEqual(log, want) {
+		t.Fatalf("disposed = %v, want %v", log, want)
+	}
+}
+
+type diSlow struct{}
+
+func TestDIContainerConcurrentSingleton(t *testing.T) {
+	container := NewDIContainer()
+	var created atomic.Int32
+	release := make(chan struct{})
+	container.MustProvide(func() *diSlow {
+		created.Add(1)
+		<-release
+		return &diSlow{}
+	}, LifetimeSingleton)
+	container.MustProvide(func() *diA { return &diA{} }, LifetimeSingleton)
+
+	results := make(chan *diSlow, 10)
+	for i := 0; i < cap(results); i++ {
+		go func() {
+			slow, err := ResolveService[*diSlow](container)
+			if err != nil {
+				t.Error(err)
+			}
+			results <- slow
+		}()
+	}
+	// 慢的构造函数不阻塞其它服务的解析
+	if _, err := ResolveService[*diA](container); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+
+	first := <-results
+	for i := 1; i < cap(results); i++ {
+		if slow := <-results; slow != first {
+			t.Fatal("concurrent resolves created different singletons")
+		}
+	}
+	if n := created.Load(); n != 1 {
+		t.Fatalf("constructor called %d times, want 1", n)
+	}
+}
@@ -0,0 +1,134 @@
+package designpattern
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// 示例的输出
+// 所有示例都通过 emit/emitf 输出，默认写到标准输出；
+// 测试或服务中可以替换为 EventRecorder 或任意 io.Writer。
+
+// Event 一条输出，Source 是产生输出的类型，例如 "HuaweiCellphone"
+type Event struct {
+	Source  string
+	Message string
+}
+
+// EventSink 接收示例的输出
+type EventSink interface {
+	Emit(event Event)
+}
+
+// EventSinkFunc 把函数适配为 EventSink
+type EventSinkFunc func(event Event)
+
+func (f EventSinkFunc) Emit(event Event) {
+	f(event)
+}
+
+// writerSink 把每条输出的 Message 作为一行写入 io.Writer
+type writerSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterSink(w io.Writer) EventSink {
+	return &writerSink{w: w}
+}
+
+func (s *writerSink) Emit(event Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprintln(s.w, event.Message)
+}
+
+// stdoutSink 每次输出时才取 os.Stdout，重定向 os.Stdout 后依然生效
+type stdoutSink struct{}
+
+func (stdoutSink) Emit(event Event) {
+	fmt.Fprintln(os.Stdout, event.Message)
+}
+
+// EventRecorder 按顺序记录所有输出，可并发使用
+type EventRecorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func NewEventRecorder() *EventRecorder {
+	return &EventRecorder{}
+}
+
+func (r *EventRecorder) Emit(event Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+// Events 返回已记录输出的副本
+func (r *EventRecorder) Events() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := make([]Event, len(r.events))
+	copy(events, r.events)
+	return events
+}
+
+// Messages 返回已记录输出的 Message
+func (r *EventRecorder) Messages() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	messages := make([]string, len(r.events))
+	for i, event := range r.events {
+		messages[i] = event.Message
+	}
+	return messages
+}
+
+// Reset 清空已记录的输出
+func (r *EventRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = nil
+}
+
+var (
+	sinkMu sync.RWMutex
+	sink   EventSink = stdoutSink{}
+)
+
+// SetEventSink 替换所有示例的输出，返回恢复原输出的函数。传入 nil 时丢弃输出。
+func SetEventSink(s EventSink) (restore func()) {
+	if s == nil {
+		s = EventSinkFunc(func(Event) {})
+	}
+	sinkMu.Lock()
+	previous := sink
+	sink = s
+	sinkMu.Unlock()
+	return func() {
+		sinkMu.Lock()
+		sink = previous
+		sinkMu.Unlock()
+	}
+}
+
+// SetOutput 把所有示例的输出写到 w，返回恢复原输出的函数
+func SetOutput(w io.Writer) (restore func()) {
+	return SetEventSink(NewWriterSink(w))
+}
+
+func emit(source, message string) {
+	sinkMu.RLock()
+	s := sink
+	sinkMu.RUnlock()
+	s.Emit(Event{Source: source, Message: message})
+}
+
+func emitf(source, format string, args ...interface{}) {
+	emit(source, strings.TrimSuffix(fmt.Sprintf(format, args...), "\n"))
+}
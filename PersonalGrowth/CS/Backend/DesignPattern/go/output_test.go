@@ -0,0 +1,55 @@
+package designpattern
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// recordOutput 在测试期间记录所有示例的输出
+func recordOutput(t *testing.T) *EventRecorder {
+	t.Helper()
+	recorder := NewEventRecorder()
+	t.Cleanup(SetEventSink(recorder))
+	return recorder
+}
+
+func assertMessages(t *testing.T, recorder *EventRecorder, want ...string) {
+	t.Helper()
+	got := recorder.Messages()
+	if len(want) == 0 {
+		want = []string{}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("messages = %q, want %q", got, want)
+	}
+}
+
+func TestEventRecorder(t *testing.T) {
+	recorder := recordOutput(t)
+	(&HuaweiCellphone{}).Call()
+	(&XiaomiSmartSoundBox{}).Listen()
+
+	want := []Event{
+		{Source: "HuaweiCellphone", Message: "I made a call on my HuaweiCellphone"},
+		{Source: "XiaomiSmartSoundBox", Message: "I am listening with XiaomiSmartSoundBox"},
+	}
+	if got := recorder.Events(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Events() = %v, want %v", got, want)
+	}
+
+	recorder.Reset()
+	assertMessages(t, recorder)
+}
+
+func TestSetOutput(t *testing.T) {
+	var buf bytes.Buffer
+	restore := SetOutput(&buf)
+	NewCircleShape(1, 2, 3, &DrawingAPI1{}).Draw()
+	NewFile("a.txt", 10).Print("  ")
+	restore()
+
+	if got, want := buf.String(), "API1.circle at 1:2 radius 3\n  - a.txt (10 bytes)\n"; got != want {
+		t.Fatalf("output = %q, want %q", got, want)
+	}
+}